@@ -0,0 +1,2 @@
+-- Create index "feature_owners_feature_id_user_id_key" to table: "feature_owners"
+CREATE UNIQUE INDEX "feature_owners_feature_id_user_id_key" ON "public"."feature_owners" ("feature_id", "user_id");
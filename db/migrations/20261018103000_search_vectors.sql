@@ -0,0 +1,8 @@
+-- Modify "features" table
+ALTER TABLE "public"."features" ADD COLUMN "search_vector" tsvector NULL GENERATED ALWAYS AS (setweight(to_tsvector('english'::regconfig, COALESCE(name, ''::text)), 'A'::"char") || setweight(to_tsvector('english'::regconfig, COALESCE(description, ''::text)), 'B'::"char")) STORED;
+-- Create index "features_search_vector_idx" to table: "features"
+CREATE INDEX "features_search_vector_idx" ON "public"."features" USING GIN ("search_vector");
+-- Modify "tasks" table
+ALTER TABLE "public"."tasks" ADD COLUMN "search_vector" tsvector NULL GENERATED ALWAYS AS (setweight(to_tsvector('english'::regconfig, COALESCE(name, ''::text)), 'A'::"char") || setweight(to_tsvector('english'::regconfig, COALESCE(description, ''::text)), 'B'::"char")) STORED;
+-- Create index "tasks_search_vector_idx" to table: "tasks"
+CREATE INDEX "tasks_search_vector_idx" ON "public"."tasks" USING GIN ("search_vector");
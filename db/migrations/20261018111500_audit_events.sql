@@ -0,0 +1,13 @@
+-- Create "audit_events" table
+CREATE TABLE "public"."audit_events" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "entity_type" text NOT NULL,
+  "entity_id" uuid NOT NULL,
+  "action" text NOT NULL,
+  "actor_id" uuid NULL,
+  "changes" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create index "audit_events_entity_idx" to table: "audit_events"
+CREATE INDEX "audit_events_entity_idx" ON "public"."audit_events" ("entity_type", "entity_id", "created_at");
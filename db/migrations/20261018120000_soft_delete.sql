@@ -0,0 +1,8 @@
+-- Modify "features" table
+ALTER TABLE "public"."features" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "features_deleted_at_idx" to table: "features"
+CREATE INDEX "features_deleted_at_idx" ON "public"."features" ("deleted_at") WHERE (deleted_at IS NOT NULL);
+-- Modify "tasks" table
+ALTER TABLE "public"."tasks" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Create index "tasks_deleted_at_idx" to table: "tasks"
+CREATE INDEX "tasks_deleted_at_idx" ON "public"."tasks" ("deleted_at") WHERE (deleted_at IS NOT NULL);
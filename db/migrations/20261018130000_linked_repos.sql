@@ -0,0 +1,2 @@
+-- Modify "features" table
+ALTER TABLE "public"."features" ADD COLUMN "repos" jsonb NOT NULL DEFAULT '[]';
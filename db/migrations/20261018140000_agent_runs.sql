@@ -0,0 +1,27 @@
+-- Create "agent_runs" table
+CREATE TABLE "public"."agent_runs" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "task_id" uuid NOT NULL,
+  "status" text NOT NULL DEFAULT 'queued',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "max_attempts" integer NOT NULL,
+  "run_after" timestamptz NOT NULL DEFAULT now(),
+  "locked_until" timestamptz NULL,
+  "provider" text NULL,
+  "model" text NULL,
+  "prompt" text NULL,
+  "output" text NULL,
+  "error" text NULL,
+  "logs" text NOT NULL DEFAULT '',
+  "created_by" uuid NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "updated_at" timestamptz NOT NULL DEFAULT now(),
+  "started_at" timestamptz NULL,
+  "finished_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "agent_runs_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "agent_runs_task_id_idx" to table: "agent_runs"
+CREATE INDEX "agent_runs_task_id_idx" ON "public"."agent_runs" ("task_id", "created_at");
+-- Create index "agent_runs_claim_idx" to table: "agent_runs"
+CREATE INDEX "agent_runs_claim_idx" ON "public"."agent_runs" ("run_after") WHERE (status = ANY (ARRAY['queued'::text, 'running'::text]));
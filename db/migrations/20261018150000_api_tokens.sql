@@ -0,0 +1,17 @@
+-- Create "api_tokens" table
+CREATE TABLE "public"."api_tokens" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "name" text NOT NULL,
+  "token_hash" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "expires_at" timestamptz NULL,
+  "last_used_at" timestamptz NULL,
+  "revoked_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "api_tokens_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "api_tokens_token_hash_key" to table: "api_tokens"
+CREATE UNIQUE INDEX "api_tokens_token_hash_key" ON "public"."api_tokens" ("token_hash");
+-- Create index "api_tokens_user_id_idx" to table: "api_tokens"
+CREATE INDEX "api_tokens_user_id_idx" ON "public"."api_tokens" ("user_id");
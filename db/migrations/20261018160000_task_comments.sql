@@ -0,0 +1,19 @@
+-- Create "task_comments" table
+CREATE TABLE "public"."task_comments" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "task_id" uuid NOT NULL,
+  "parent_id" uuid NULL,
+  "author_id" uuid NULL,
+  "author_name" text NOT NULL,
+  "agent_run_id" uuid NULL,
+  "body" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "edited_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "task_comments_agent_run_id_fkey" FOREIGN KEY ("agent_run_id") REFERENCES "public"."agent_runs" ("id") ON UPDATE CASCADE ON DELETE SET NULL,
+  CONSTRAINT "task_comments_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "public"."users" ("id") ON UPDATE CASCADE ON DELETE SET NULL,
+  CONSTRAINT "task_comments_parent_id_fkey" FOREIGN KEY ("parent_id") REFERENCES "public"."task_comments" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "task_comments_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "task_comments_task_id_idx" to table: "task_comments"
+CREATE INDEX "task_comments_task_id_idx" ON "public"."task_comments" ("task_id", "created_at");
@@ -0,0 +1,12 @@
+-- Create "task_dependencies" table
+CREATE TABLE "public"."task_dependencies" (
+  "blocker_id" uuid NOT NULL,
+  "blocked_id" uuid NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("blocker_id", "blocked_id"),
+  CONSTRAINT "task_dependencies_blocked_id_fkey" FOREIGN KEY ("blocked_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "task_dependencies_blocker_id_fkey" FOREIGN KEY ("blocker_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "task_dependencies_not_self" CHECK (blocker_id <> blocked_id)
+);
+-- Create index "task_dependencies_blocked_id_idx" to table: "task_dependencies"
+CREATE INDEX "task_dependencies_blocked_id_idx" ON "public"."task_dependencies" ("blocked_id");
@@ -0,0 +1,4 @@
+-- Modify "tasks" table
+ALTER TABLE "public"."tasks" ADD COLUMN "parent_task_id" uuid NULL, ADD CONSTRAINT "tasks_parent_task_id_fkey" FOREIGN KEY ("parent_task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE;
+-- Create index "tasks_parent_task_id_idx" to table: "tasks"
+CREATE INDEX "tasks_parent_task_id_idx" ON "public"."tasks" ("parent_task_id");
@@ -0,0 +1,32 @@
+-- Create "labels" table
+CREATE TABLE "public"."labels" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "name" text NOT NULL,
+  "color" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  "updated_at" timestamptz NOT NULL DEFAULT now(),
+  "created_by" uuid NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "labels_name_key" to table: "labels"
+CREATE UNIQUE INDEX "labels_name_key" ON "public"."labels" ((lower(name)));
+-- Create "feature_labels" table
+CREATE TABLE "public"."feature_labels" (
+  "feature_id" uuid NOT NULL,
+  "label_id" uuid NOT NULL,
+  PRIMARY KEY ("feature_id", "label_id"),
+  CONSTRAINT "feature_labels_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "public"."features" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "feature_labels_label_id_fkey" FOREIGN KEY ("label_id") REFERENCES "public"."labels" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "feature_labels_label_id_idx" to table: "feature_labels"
+CREATE INDEX "feature_labels_label_id_idx" ON "public"."feature_labels" ("label_id");
+-- Create "task_labels" table
+CREATE TABLE "public"."task_labels" (
+  "task_id" uuid NOT NULL,
+  "label_id" uuid NOT NULL,
+  PRIMARY KEY ("task_id", "label_id"),
+  CONSTRAINT "task_labels_label_id_fkey" FOREIGN KEY ("label_id") REFERENCES "public"."labels" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "task_labels_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "task_labels_label_id_idx" to table: "task_labels"
+CREATE INDEX "task_labels_label_id_idx" ON "public"."task_labels" ("label_id");
@@ -0,0 +1,14 @@
+-- Create "task_assignees" table
+CREATE TABLE "public"."task_assignees" (
+  "task_id" uuid NOT NULL,
+  "user_id" uuid NOT NULL,
+  "assigned_at" timestamptz NOT NULL DEFAULT now(),
+  "assigned_by" uuid NULL,
+  PRIMARY KEY ("task_id", "user_id"),
+  CONSTRAINT "task_assignees_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "task_assignees_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+-- Create index "task_assignees_user_id_idx" to table: "task_assignees"
+CREATE INDEX "task_assignees_user_id_idx" ON "public"."task_assignees" ("user_id");
+-- Add the AI agent as a user so tasks can be assigned to it
+INSERT INTO "public"."users" ("id", "name", "role") VALUES ('00000000-0000-0000-0000-000000000001', 'AI agent', 'agent') ON CONFLICT DO NOTHING;
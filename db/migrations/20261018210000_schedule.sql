@@ -0,0 +1,6 @@
+-- Modify "features" table
+ALTER TABLE "public"."features" ADD COLUMN "target_date" date NULL;
+-- Modify "tasks" table
+ALTER TABLE "public"."tasks" ADD CONSTRAINT "tasks_estimate_points_check" CHECK (estimate_points >= 0), ADD COLUMN "start_date" date NULL, ADD COLUMN "due_date" date NULL, ADD COLUMN "estimate_points" integer NULL;
+-- Create index "tasks_due_date_idx" to table: "tasks"
+CREATE INDEX "tasks_due_date_idx" ON "public"."tasks" ("due_date");
@@ -0,0 +1,21 @@
+-- Create "work_logs" table
+CREATE TABLE "public"."work_logs" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "task_id" uuid NOT NULL,
+  "user_id" uuid NULL,
+  "user_name" text NOT NULL,
+  "started_at" timestamptz NOT NULL,
+  "ended_at" timestamptz NULL,
+  "note" text NULL,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "work_logs_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "public"."tasks" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "work_logs_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE CASCADE ON DELETE SET NULL,
+  CONSTRAINT "work_logs_ended_after_started" CHECK ((ended_at IS NULL) OR (ended_at >= started_at))
+);
+-- Create index "work_logs_running_timer_key" to table: "work_logs"
+CREATE UNIQUE INDEX "work_logs_running_timer_key" ON "public"."work_logs" ("user_id") WHERE (ended_at IS NULL);
+-- Create index "work_logs_started_at_idx" to table: "work_logs"
+CREATE INDEX "work_logs_started_at_idx" ON "public"."work_logs" ("started_at");
+-- Create index "work_logs_task_id_idx" to table: "work_logs"
+CREATE INDEX "work_logs_task_id_idx" ON "public"."work_logs" ("task_id", "started_at");
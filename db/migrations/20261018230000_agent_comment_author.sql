@@ -0,0 +1,2 @@
+-- Attribute the summaries agent runs posted to the AI agent user
+UPDATE "public"."task_comments" SET "author_id" = '00000000-0000-0000-0000-000000000001' WHERE "agent_run_id" IS NOT NULL AND "author_id" IS NULL;
@@ -0,0 +1,20 @@
+-- Wrap git_data written before it had a schema in "legacy", so branch, pull
+-- request and webhook lookups can read every task. See domain.GitData.
+UPDATE "public"."tasks"
+SET "git_data" = jsonb_build_object('repos', '[]'::jsonb, 'branches', '[]'::jsonb, 'commits', '[]'::jsonb, 'pull_requests', '[]'::jsonb, 'legacy', "git_data")
+WHERE "git_data" IS NOT NULL AND "git_data" <> 'null'::jsonb AND (
+  jsonb_typeof("git_data") <> 'object'
+  OR EXISTS (
+    SELECT 1 FROM jsonb_object_keys("git_data") AS "key"
+    WHERE "key" NOT IN ('repos', 'branches', 'commits', 'pull_requests', 'legacy')
+  )
+  OR EXISTS (
+    SELECT 1 FROM unnest(ARRAY['repos', 'branches', 'commits', 'pull_requests']) AS "key"
+    WHERE jsonb_typeof("git_data" -> "key") NOT IN ('array', 'null')
+  )
+  OR EXISTS (
+    SELECT 1 FROM unnest(ARRAY['repos', 'branches', 'commits', 'pull_requests']) AS "key",
+      jsonb_array_elements(CASE WHEN jsonb_typeof("git_data" -> "key") = 'array' THEN "git_data" -> "key" ELSE '[]'::jsonb END) AS "element"
+    WHERE jsonb_typeof("element") <> 'object'
+  )
+);
@@ -0,0 +1,91 @@
+-- name: CreateAgentRun :one
+INSERT INTO agent_runs (
+    task_id, max_attempts, created_by
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetAgentRun :one
+SELECT * FROM agent_runs
+WHERE id = $1;
+
+-- name: ListAgentRunsByTask :many
+SELECT * FROM agent_runs
+WHERE task_id = $1
+ORDER BY created_at DESC;
+
+-- name: ClaimAgentRun :one
+-- Picks the next run that is due, or whose worker stopped renewing its lease,
+-- and marks it running. Concurrent workers skip rows another one has locked.
+UPDATE agent_runs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until)::timestamptz,
+    started_at = NOW(),
+    updated_at = NOW()
+WHERE id = (
+    SELECT id FROM agent_runs
+    WHERE
+        (status = 'queued' AND run_after <= NOW())
+        OR (status = 'running' AND locked_until < NOW())
+    ORDER BY run_after, created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ExtendAgentRunLease :execrows
+-- Returns 0 when the run is no longer running, e.g. because it was cancelled.
+UPDATE agent_runs
+SET locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: AppendAgentRunLog :exec
+UPDATE agent_runs
+SET logs = logs || sqlc.arg(line)::text, updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: CompleteAgentRun :execrows
+UPDATE agent_runs
+SET
+    status = 'succeeded',
+    provider = sqlc.arg(provider),
+    model = sqlc.arg(model),
+    prompt = sqlc.arg(prompt),
+    output = sqlc.arg(output),
+    error = NULL,
+    locked_until = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: RetryAgentRun :execrows
+UPDATE agent_runs
+SET
+    status = 'queued',
+    error = sqlc.arg(error),
+    run_after = sqlc.arg(run_after)::timestamptz,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: FailAgentRun :execrows
+UPDATE agent_runs
+SET
+    status = 'failed',
+    error = sqlc.arg(error),
+    locked_until = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'running';
+
+-- name: CancelAgentRun :one
+UPDATE agent_runs
+SET
+    status = 'cancelled',
+    locked_until = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status IN ('queued', 'running')
+RETURNING *;
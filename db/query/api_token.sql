@@ -0,0 +1,30 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+    user_id, name, token_hash, expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetActiveAPITokenByHash :one
+-- Revoked and expired tokens are never returned.
+SELECT * FROM api_tokens
+WHERE
+    token_hash = $1
+    AND revoked_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: ListAPITokensByUser :many
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeAPIToken :one
+UPDATE api_tokens
+SET revoked_at = NOW()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND revoked_at IS NULL
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+    entity_type,
+    entity_id,
+    action,
+    actor_id,
+    changes
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE entity_type = $1 AND entity_id = $2
+ORDER BY created_at DESC, id DESC;
@@ -1,30 +1,134 @@
 -- name: CreateFeature :one
 INSERT INTO features (
-    id, name, description, created_at, updated_at, created_by, priority, status
+    id, name, description, created_at, updated_at, created_by, priority, status, repos, target_date
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: ListFeatures :many
+-- Filters are optional; sorting and keyset pagination are driven by sort_by,
+-- descending and the cursor_* arguments.
 SELECT * FROM features
-ORDER BY created_at DESC;
+WHERE
+    deleted_at IS NULL
+    AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+    AND (sqlc.narg(priority)::text IS NULL OR priority = sqlc.narg(priority)::text)
+    AND (sqlc.narg(created_by)::uuid IS NULL OR created_by = sqlc.narg(created_by)::uuid)
+    AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after)::timestamptz)
+    AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before)::timestamptz)
+    AND (sqlc.narg(updated_after)::timestamptz IS NULL OR updated_at >= sqlc.narg(updated_after)::timestamptz)
+    AND (sqlc.narg(updated_before)::timestamptz IS NULL OR updated_at < sqlc.narg(updated_before)::timestamptz)
+    AND (
+        sqlc.narg(cursor_id)::uuid IS NULL
+        OR (
+            NOT sqlc.arg(descending)::bool
+            AND (
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity'),
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, ''),
+                id
+            ) > (
+                COALESCE(sqlc.narg(cursor_time)::timestamptz, '-infinity'),
+                COALESCE(sqlc.narg(cursor_text)::text, ''),
+                sqlc.narg(cursor_id)::uuid
+            )
+        )
+        OR (
+            sqlc.arg(descending)::bool
+            AND (
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity'),
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, ''),
+                id
+            ) < (
+                COALESCE(sqlc.narg(cursor_time)::timestamptz, '-infinity'),
+                COALESCE(sqlc.narg(cursor_text)::text, ''),
+                sqlc.narg(cursor_id)::uuid
+            )
+        )
+    )
+ORDER BY
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity') END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity') END DESC,
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, '') END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, '') END DESC,
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN id END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN id END DESC
+LIMIT sqlc.arg(page_limit)::int;
 
 -- name: UpdateFeature :one
+-- set_target_date tells a target date to clear (NULL) from one to keep.
 UPDATE features
 SET
-    name = $2,
-    description = $3,
-    updated_at = $4,
-    created_by = $5,
-    priority = $6,
-    status = $7
-WHERE id = $1
+    name = sqlc.arg(name),
+    description = sqlc.narg(description),
+    updated_at = sqlc.arg(updated_at),
+    priority = sqlc.narg(priority),
+    status = sqlc.narg(status),
+    repos = sqlc.arg(repos),
+    target_date = CASE WHEN sqlc.arg(set_target_date)::bool THEN sqlc.narg(target_date)::date ELSE target_date END
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
 RETURNING *;
 
+-- name: SetFeatureStatus :one
+UPDATE features
+SET status = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ListFeatureProgress :many
+-- Counts the tasks of each of the given features, subtasks included, and
+-- sums their estimates, per status. Features without tasks are left out.
+SELECT
+    feature_id,
+    status,
+    COUNT(*) AS tasks,
+    COALESCE(SUM(estimate_points), 0)::bigint AS points
+FROM tasks
+WHERE feature_id = ANY(sqlc.arg(feature_ids)::uuid[]) AND deleted_at IS NULL
+GROUP BY feature_id, status;
+
 -- name: DeleteFeature :exec
-DELETE FROM features
-WHERE id = $1;
+-- Moves the feature to the trash; PurgeDeletedFeatures removes it for good.
+UPDATE features
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetFeature :one
 SELECT * FROM features
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetFeatureForUpdate :one
+SELECT * FROM features
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetDeletedFeatureForUpdate :one
+SELECT * FROM features
+WHERE id = $1 AND deleted_at IS NOT NULL
+FOR UPDATE;
+
+-- name: ListDeletedFeatures :many
+SELECT * FROM features
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreFeature :one
+UPDATE features
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedFeatureOwners :exec
+-- Owners go first because feature_owners restricts deleting features.
+DELETE FROM feature_owners
+WHERE feature_id IN (
+    SELECT features.id FROM features
+    WHERE features.deleted_at < sqlc.arg(deleted_before)::timestamptz
+    AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.feature_id = features.id)
+);
+
+-- name: PurgeDeletedFeatures :execrows
+-- Features still referenced by a task (even a trashed one) are kept until the
+-- task is purged.
+DELETE FROM features
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz
+AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.feature_id = features.id);
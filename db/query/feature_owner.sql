@@ -0,0 +1,41 @@
+-- name: AddFeatureOwner :one
+INSERT INTO feature_owners (
+    feature_id,
+    user_id,
+    user_name,
+    user_role
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (feature_id, user_id) DO UPDATE
+SET
+    user_name = EXCLUDED.user_name,
+    user_role = EXCLUDED.user_role
+RETURNING *;
+
+-- name: ListFeatureOwners :many
+SELECT * FROM feature_owners
+WHERE feature_id = $1
+ORDER BY user_name;
+
+-- name: ListFeatureOwnersByFeatureIDs :many
+SELECT * FROM feature_owners
+WHERE feature_id = ANY(sqlc.arg(feature_ids)::uuid[])
+ORDER BY user_name;
+
+-- name: IsFeatureOwner :one
+SELECT EXISTS (
+    SELECT 1 FROM feature_owners
+    WHERE feature_id = $1 AND user_id = $2
+);
+
+-- name: RemoveFeatureOwner :exec
+DELETE FROM feature_owners
+WHERE feature_id = $1 AND user_id = $2;
+
+-- name: SyncFeatureOwnerUser :exec
+UPDATE feature_owners
+SET
+    user_name = $2,
+    user_role = $3
+WHERE user_id = $1;
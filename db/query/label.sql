@@ -0,0 +1,62 @@
+-- name: CreateLabel :one
+INSERT INTO labels (
+    name,
+    color,
+    created_by
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetLabel :one
+SELECT * FROM labels
+WHERE id = $1;
+
+-- name: ListLabels :many
+SELECT * FROM labels
+ORDER BY lower(name);
+
+-- name: UpdateLabel :one
+UPDATE labels
+SET
+    name = COALESCE(sqlc.narg(name), name),
+    color = COALESCE(sqlc.narg(color), color),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteLabel :execrows
+-- Detaches the label from every task and feature.
+DELETE FROM labels
+WHERE id = $1;
+
+-- name: AddTaskLabel :execrows
+INSERT INTO task_labels (task_id, label_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveTaskLabel :execrows
+DELETE FROM task_labels
+WHERE task_id = $1 AND label_id = $2;
+
+-- name: AddFeatureLabel :execrows
+INSERT INTO feature_labels (feature_id, label_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveFeatureLabel :execrows
+DELETE FROM feature_labels
+WHERE feature_id = $1 AND label_id = $2;
+
+-- name: ListLabelsByTaskIDs :many
+SELECT task_labels.task_id, sqlc.embed(labels)
+FROM task_labels
+JOIN labels ON labels.id = task_labels.label_id
+WHERE task_labels.task_id = ANY(sqlc.arg(task_ids)::uuid[])
+ORDER BY lower(labels.name);
+
+-- name: ListLabelsByFeatureIDs :many
+SELECT feature_labels.feature_id, sqlc.embed(labels)
+FROM feature_labels
+JOIN labels ON labels.id = feature_labels.label_id
+WHERE feature_labels.feature_id = ANY(sqlc.arg(feature_ids)::uuid[])
+ORDER BY lower(labels.name);
@@ -0,0 +1,28 @@
+-- name: Search :many
+-- Ranks tasks and features together. Names weigh more than descriptions
+-- (see the search_vector columns). Headlines mark matches with chr(2) and
+-- chr(3), which SearchService turns into <mark> once the text is escaped.
+WITH search AS (
+    SELECT websearch_to_tsquery('english', sqlc.arg(query)::text) AS query
+)
+SELECT
+    'task'::text AS kind,
+    tasks.id,
+    tasks.feature_id,
+    ts_headline('english', tasks.name, search.query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::text AS name_highlight,
+    ts_headline('english', COALESCE(tasks.description, ''), search.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxWords=35, MinWords=15')::text AS snippet,
+    ts_rank(tasks.search_vector, search.query)::float8 AS rank
+FROM tasks, search
+WHERE tasks.search_vector @@ search.query AND tasks.deleted_at IS NULL
+UNION ALL
+SELECT
+    'feature'::text AS kind,
+    features.id,
+    NULL::uuid AS feature_id,
+    ts_headline('english', features.name, search.query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3))::text AS name_highlight,
+    ts_headline('english', COALESCE(features.description, ''), search.query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxWords=35, MinWords=15')::text AS snippet,
+    ts_rank(features.search_vector, search.query)::float8 AS rank
+FROM features, search
+WHERE features.search_vector @@ search.query AND features.deleted_at IS NULL
+ORDER BY rank DESC
+LIMIT sqlc.arg(result_limit)::int;
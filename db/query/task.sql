@@ -7,14 +7,91 @@ INSERT INTO tasks (
     feature_name,
     priority,
     status,
-    git_data
+    git_data,
+    parent_task_id,
+    start_date,
+    due_date,
+    estimate_points
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 ) RETURNING *;
 
 -- name: ListTasks :many
+-- Filters are optional; sorting and keyset pagination are driven by sort_by,
+-- descending and the cursor_* arguments.
 SELECT * FROM tasks
-ORDER BY created_at DESC;
+WHERE
+    deleted_at IS NULL
+    AND (sqlc.narg(feature_id)::uuid IS NULL OR feature_id = sqlc.narg(feature_id)::uuid)
+    AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+    AND (sqlc.narg(priority)::text IS NULL OR priority = sqlc.narg(priority)::text)
+    AND (sqlc.narg(created_by)::uuid IS NULL OR created_by = sqlc.narg(created_by)::uuid)
+    AND (sqlc.narg(parent_task_id)::uuid IS NULL OR parent_task_id = sqlc.narg(parent_task_id)::uuid)
+    AND (NOT sqlc.arg(top_level)::bool OR parent_task_id IS NULL)
+    AND (
+        sqlc.narg(label)::text IS NULL
+        OR EXISTS (
+            SELECT 1 FROM task_labels
+            JOIN labels ON labels.id = task_labels.label_id
+            WHERE task_labels.task_id = tasks.id
+                AND lower(labels.name) = lower(sqlc.narg(label)::text)
+        )
+    )
+    AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after)::timestamptz)
+    AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before)::timestamptz)
+    AND (sqlc.narg(updated_after)::timestamptz IS NULL OR updated_at >= sqlc.narg(updated_after)::timestamptz)
+    AND (sqlc.narg(updated_before)::timestamptz IS NULL OR updated_at < sqlc.narg(updated_before)::timestamptz)
+    AND (sqlc.narg(due_before)::date IS NULL OR due_date < sqlc.narg(due_before)::date)
+    AND (
+        NOT sqlc.arg(overdue)::bool
+        OR (
+            due_date < CURRENT_DATE
+            AND (status IS NULL OR NOT status = ANY(sqlc.arg(closed_statuses)::text[]))
+        )
+    )
+    AND (
+        sqlc.narg(repo_owner)::text IS NULL
+        OR EXISTS (
+            SELECT 1 FROM jsonb_array_elements(COALESCE(git_data->'repos', '[]')) AS repo
+            WHERE lower(repo->>'owner') = lower(sqlc.narg(repo_owner)::text)
+                AND lower(repo->>'name') = lower(sqlc.narg(repo_name)::text)
+        )
+    )
+    AND (
+        sqlc.narg(cursor_id)::uuid IS NULL
+        OR (
+            NOT sqlc.arg(descending)::bool
+            AND (
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity'),
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, ''),
+                id
+            ) > (
+                COALESCE(sqlc.narg(cursor_time)::timestamptz, '-infinity'),
+                COALESCE(sqlc.narg(cursor_text)::text, ''),
+                sqlc.narg(cursor_id)::uuid
+            )
+        )
+        OR (
+            sqlc.arg(descending)::bool
+            AND (
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity'),
+                COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, ''),
+                id
+            ) < (
+                COALESCE(sqlc.narg(cursor_time)::timestamptz, '-infinity'),
+                COALESCE(sqlc.narg(cursor_text)::text, ''),
+                sqlc.narg(cursor_id)::uuid
+            )
+        )
+    )
+ORDER BY
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity') END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'created_at' THEN created_at WHEN 'updated_at' THEN updated_at END, '-infinity') END DESC,
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, '') END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN COALESCE(CASE sqlc.arg(sort_by)::text WHEN 'name' THEN name WHEN 'priority' THEN priority WHEN 'status' THEN status END, '') END DESC,
+    CASE WHEN NOT sqlc.arg(descending)::bool THEN id END ASC,
+    CASE WHEN sqlc.arg(descending)::bool THEN id END DESC
+LIMIT sqlc.arg(page_limit)::int;
 
 -- name: UpdateTask :one
 UPDATE tasks
@@ -25,14 +102,145 @@ SET
     feature_name = COALESCE(sqlc.narg(feature_name), feature_name),
     priority = COALESCE(sqlc.narg(priority), priority),
     status = COALESCE(sqlc.narg(status), status),
-    git_data = COALESCE(sqlc.narg(git_data), git_data)
-WHERE id = sqlc.arg(id)
+    git_data = COALESCE(sqlc.narg(git_data), git_data),
+    -- The set_* flags tell a value to clear (NULL) from one to keep.
+    start_date = CASE WHEN sqlc.arg(set_start_date)::bool THEN sqlc.narg(start_date)::date ELSE start_date END,
+    due_date = CASE WHEN sqlc.arg(set_due_date)::bool THEN sqlc.narg(due_date)::date ELSE due_date END,
+    estimate_points = CASE WHEN sqlc.arg(set_estimate_points)::bool THEN sqlc.narg(estimate_points)::int ELSE estimate_points END
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteTask :exec
-DELETE FROM tasks
-WHERE id = $1;
+-- Moves the task to the trash; PurgeDeletedTasks removes it for good.
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetTask :one
 SELECT * FROM tasks
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetTaskForUpdate :one
+SELECT * FROM tasks
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ListFeatureTasksForUpdate :many
+SELECT * FROM tasks
+WHERE feature_id = $1 AND deleted_at IS NULL
+ORDER BY created_at, id
+FOR UPDATE;
+
+-- name: ListTaskIDsByGitBranch :many
+-- Tasks whose git_data links the branch, directly or through a pull request.
+SELECT id FROM tasks
+WHERE
+    deleted_at IS NULL
+    AND (
+        EXISTS (
+            SELECT 1 FROM jsonb_array_elements(COALESCE(git_data->'branches', '[]')) AS branch
+            WHERE lower(branch->>'repo') = lower(sqlc.arg(repo)::text)
+                AND branch->>'name' = sqlc.arg(branch)::text
+        )
+        OR EXISTS (
+            SELECT 1 FROM jsonb_array_elements(COALESCE(git_data->'pull_requests', '[]')) AS pr
+            WHERE lower(pr->>'repo') = lower(sqlc.arg(repo)::text)
+                AND pr->>'branch' = sqlc.arg(branch)::text
+        )
+    )
+ORDER BY created_at, id;
+
+-- name: GetDeletedTaskForUpdate :one
+SELECT * FROM tasks
+WHERE id = $1 AND deleted_at IS NOT NULL
+FOR UPDATE;
+
+-- name: ListDeletedTasks :many
+SELECT * FROM tasks
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreTask :one
+UPDATE tasks
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedTasks :execrows
+-- Tasks with work logs stay in the trash for good, since the time report
+-- bills them, and so do the tasks above them, whose deletion would cascade
+-- to their subtasks.
+DELETE FROM tasks
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz
+AND id NOT IN (
+    WITH RECURSIVE logged AS (
+        SELECT work_logs.task_id AS id FROM work_logs
+        UNION
+        SELECT tasks.parent_task_id FROM tasks
+        JOIN logged ON tasks.id = logged.id
+        WHERE tasks.parent_task_id IS NOT NULL
+    )
+    SELECT logged.id FROM logged
+);
+
+-- name: ListFeatureTasks :many
+SELECT * FROM tasks
+WHERE feature_id = $1 AND deleted_at IS NULL
+ORDER BY created_at, id;
+
+-- name: ListSubtaskTree :many
+-- All subtasks of a task, nested at any depth, that aren't in the trash.
+SELECT * FROM tasks
+WHERE deleted_at IS NULL AND id IN (
+    WITH RECURSIVE subtree (id) AS (
+        SELECT t.id FROM tasks t
+        WHERE t.parent_task_id = sqlc.arg(id)::uuid AND t.deleted_at IS NULL
+        UNION ALL
+        SELECT t.id FROM tasks t
+        JOIN subtree ON t.parent_task_id = subtree.id
+        WHERE t.deleted_at IS NULL
+    )
+    SELECT subtree.id FROM subtree
+)
+ORDER BY created_at, id;
+
+-- name: ListTrashedSubtaskTree :many
+-- The subtasks that were moved to the trash together with the task, which
+-- share its deleted_at.
+SELECT * FROM tasks
+WHERE deleted_at = sqlc.arg(deleted_at)::timestamptz AND id IN (
+    WITH RECURSIVE subtree (id) AS (
+        SELECT t.id FROM tasks t
+        WHERE t.parent_task_id = sqlc.arg(id)::uuid
+        UNION ALL
+        SELECT t.id FROM tasks t
+        JOIN subtree ON t.parent_task_id = subtree.id
+    )
+    SELECT subtree.id FROM subtree
+)
+ORDER BY created_at, id;
+
+-- name: ListSubtaskProgress :many
+-- Counts the subtasks under each of the given tasks, at any depth, and how
+-- many of them are done. Cancelled subtasks don't count.
+WITH RECURSIVE subtree (root_id, id, status) AS (
+    SELECT t.parent_task_id, t.id, t.status FROM tasks t
+    WHERE t.parent_task_id = ANY(sqlc.arg(task_ids)::uuid[]) AND t.deleted_at IS NULL
+    UNION ALL
+    SELECT subtree.root_id, t.id, t.status FROM tasks t
+    JOIN subtree ON t.parent_task_id = subtree.id
+    WHERE t.deleted_at IS NULL
+)
+SELECT
+    root_id::uuid AS task_id,
+    COUNT(*) FILTER (WHERE status IS DISTINCT FROM sqlc.arg(cancelled_status)::text) AS total,
+    COUNT(*) FILTER (WHERE status = sqlc.arg(done_status)::text) AS done
+FROM subtree
+GROUP BY root_id;
+
+-- name: ListTrashedFeatureTasks :many
+-- The tasks that were moved to the trash together with the feature, which
+-- share its deleted_at.
+SELECT * FROM tasks
+WHERE feature_id = sqlc.arg(feature_id)::uuid AND deleted_at = sqlc.arg(deleted_at)::timestamptz
+ORDER BY created_at, id;
@@ -0,0 +1,25 @@
+-- name: AddTaskAssignee :execrows
+INSERT INTO task_assignees (task_id, user_id, assigned_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (task_id, user_id) DO NOTHING;
+
+-- name: RemoveTaskAssignee :execrows
+DELETE FROM task_assignees
+WHERE task_id = $1 AND user_id = $2;
+
+-- name: ListAssigneesByTaskIDs :many
+SELECT task_assignees.task_id, sqlc.embed(users)
+FROM task_assignees
+JOIN users ON users.id = task_assignees.user_id
+WHERE task_assignees.task_id = ANY(sqlc.arg(task_ids)::uuid[])
+ORDER BY users.name, users.id;
+
+-- name: ListAssignedTasks :many
+-- The tasks assigned to a user that aren't in the trash, most recently
+-- updated first.
+SELECT tasks.* FROM tasks
+JOIN task_assignees ON task_assignees.task_id = tasks.id
+WHERE task_assignees.user_id = sqlc.arg(user_id)
+  AND tasks.deleted_at IS NULL
+  AND (sqlc.narg(status)::text IS NULL OR tasks.status = sqlc.narg(status))
+ORDER BY tasks.updated_at DESC, tasks.id;
@@ -0,0 +1,33 @@
+-- name: CreateTaskComment :one
+INSERT INTO task_comments (
+    task_id, parent_id, author_id, author_name, agent_run_id, body
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetTaskComment :one
+SELECT * FROM task_comments
+WHERE id = $1 AND task_id = $2;
+
+-- name: ListTaskComments :many
+SELECT * FROM task_comments
+WHERE task_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateTaskComment :one
+UPDATE task_comments
+SET
+    body = $2,
+    edited_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTaskComment :exec
+-- Replies are deleted with the comment.
+DELETE FROM task_comments
+WHERE id = $1;
+
+-- name: SyncCommentAuthorName :exec
+UPDATE task_comments
+SET author_name = $2
+WHERE author_id = $1;
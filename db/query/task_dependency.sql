@@ -0,0 +1,59 @@
+-- name: LockTaskDependencies :exec
+-- Serializes changes to the dependency graph for the rest of the transaction,
+-- so concurrent additions can't close a cycle between them.
+SELECT pg_advisory_xact_lock(hashtext('task_dependencies'));
+
+-- name: TaskBlocks :one
+-- Reports whether blocker_id blocks blocked_id, directly or through other
+-- tasks.
+WITH RECURSIVE downstream (id) AS (
+    SELECT d.blocked_id FROM task_dependencies d
+    WHERE d.blocker_id = sqlc.arg(blocker_id)::uuid
+    UNION
+    SELECT d.blocked_id FROM task_dependencies d
+    JOIN downstream ON d.blocker_id = downstream.id
+)
+SELECT EXISTS (
+    SELECT 1 FROM downstream WHERE id = sqlc.arg(blocked_id)::uuid
+);
+
+-- name: AddTaskDependency :one
+INSERT INTO task_dependencies (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT (blocker_id, blocked_id) DO UPDATE SET blocker_id = EXCLUDED.blocker_id
+RETURNING *;
+
+-- name: RemoveTaskDependency :execrows
+DELETE FROM task_dependencies
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: ListOpenBlockers :many
+-- Tasks blocking the given one that aren't in one of the closed statuses.
+-- Tasks in the trash don't block.
+SELECT t.* FROM task_dependencies d
+JOIN tasks t ON t.id = d.blocker_id
+WHERE
+    d.blocked_id = sqlc.arg(blocked_id)::uuid
+    AND t.deleted_at IS NULL
+    AND (t.status IS NULL OR NOT t.status = ANY(sqlc.arg(closed_statuses)::text[]))
+ORDER BY t.name, t.id;
+
+-- name: ListTaskDependencyLinks :many
+-- Every dependency touching one of the given tasks, with both ends described.
+-- Dependencies on tasks in the trash are left out.
+SELECT
+    d.blocker_id,
+    d.blocked_id,
+    blocker.name AS blocker_name,
+    blocker.status AS blocker_status,
+    blocker.feature_id AS blocker_feature_id,
+    blocked.name AS blocked_name,
+    blocked.status AS blocked_status,
+    blocked.feature_id AS blocked_feature_id
+FROM task_dependencies d
+JOIN tasks blocker ON blocker.id = d.blocker_id AND blocker.deleted_at IS NULL
+JOIN tasks blocked ON blocked.id = d.blocked_id AND blocked.deleted_at IS NULL
+WHERE
+    d.blocker_id = ANY(sqlc.arg(task_ids)::uuid[])
+    OR d.blocked_id = ANY(sqlc.arg(task_ids)::uuid[])
+ORDER BY d.created_at, d.blocker_id, d.blocked_id;
@@ -0,0 +1,29 @@
+-- name: CreateUser :one
+INSERT INTO users (
+    name,
+    role,
+    created_by
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at DESC;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+    name = COALESCE(sqlc.narg(name), name),
+    role = COALESCE(sqlc.narg(role), role),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
@@ -0,0 +1,59 @@
+-- name: StartWorkLog :one
+-- Fails on work_logs_running_timer_key when the user already has a timer
+-- running.
+INSERT INTO work_logs (task_id, user_id, user_name, started_at)
+VALUES ($1, $2, $3, NOW())
+RETURNING *;
+
+-- name: StopWorkLog :one
+UPDATE work_logs
+SET ended_at = NOW()
+WHERE task_id = $1 AND user_id = $2 AND ended_at IS NULL
+RETURNING *;
+
+-- name: GetRunningWorkLog :one
+SELECT * FROM work_logs
+WHERE user_id = $1 AND ended_at IS NULL;
+
+-- name: CreateWorkLog :one
+INSERT INTO work_logs (task_id, user_id, user_name, started_at, ended_at, note)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetWorkLog :one
+SELECT * FROM work_logs
+WHERE id = $1 AND task_id = $2;
+
+-- name: ListTaskWorkLogs :many
+SELECT * FROM work_logs
+WHERE task_id = $1
+ORDER BY started_at, id;
+
+-- name: DeleteWorkLog :exec
+DELETE FROM work_logs
+WHERE id = $1;
+
+-- name: ListWorkLogEntries :many
+-- Finished work logs that started in [started_after, started_before), with
+-- the task and feature they were logged against. Tasks in the trash are
+-- included, since the time was spent.
+SELECT
+    sqlc.embed(work_logs),
+    tasks.name AS task_name,
+    tasks.feature_id,
+    features.name AS feature_name
+FROM work_logs
+JOIN tasks ON tasks.id = work_logs.task_id
+JOIN features ON features.id = tasks.feature_id
+WHERE
+    work_logs.ended_at IS NOT NULL
+    AND work_logs.started_at >= sqlc.arg(started_after)::timestamptz
+    AND work_logs.started_at < sqlc.arg(started_before)::timestamptz
+    AND (sqlc.narg(feature_id)::uuid IS NULL OR tasks.feature_id = sqlc.narg(feature_id)::uuid)
+    AND (sqlc.narg(user_id)::uuid IS NULL OR work_logs.user_id = sqlc.narg(user_id)::uuid)
+ORDER BY work_logs.started_at, work_logs.id;
+
+-- name: SyncWorkLogUserName :exec
+UPDATE work_logs
+SET user_name = sqlc.arg(user_name)
+WHERE user_id = sqlc.arg(user_id);
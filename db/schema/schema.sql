@@ -20,10 +20,17 @@ CREATE TABLE "features" (
     "created_by" UUID,
     "priority" TEXT,
     "status" TEXT,
+    "search_vector" TSVECTOR GENERATED ALWAYS AS (setweight(to_tsvector('english', COALESCE("name", '')), 'A') || setweight(to_tsvector('english', COALESCE("description", '')), 'B')) STORED,
+    "deleted_at" TIMESTAMPTZ, -- Set when the feature is moved to the trash
+    "repos" JSONB NOT NULL DEFAULT '[]', -- Linked repositories, see domain.GitRepo
+    "target_date" DATE, -- When the feature should ship
 
     CONSTRAINT "features_pkey" PRIMARY KEY ("id")
 );
 
+CREATE INDEX "features_search_vector_idx" ON "features" USING GIN ("search_vector");
+CREATE INDEX "features_deleted_at_idx" ON "features"("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
 -- CreateTable for Tasks
 CREATE TABLE "tasks" (
     "id" UUID NOT NULL DEFAULT gen_random_uuid(),
@@ -36,12 +43,25 @@ CREATE TABLE "tasks" (
     "feature_name" TEXT, -- Denormalized for easier querying, consider a view or join if this becomes problematic
     "priority" TEXT,
     "status" TEXT,
-    "git_data" JSONB,
+    "git_data" JSONB, -- See domain.GitData
+    "search_vector" TSVECTOR GENERATED ALWAYS AS (setweight(to_tsvector('english', COALESCE("name", '')), 'A') || setweight(to_tsvector('english', COALESCE("description", '')), 'B')) STORED,
+    "deleted_at" TIMESTAMPTZ, -- Set when the task is moved to the trash
+    "parent_task_id" UUID, -- Set on subtasks, which share their parent's feature
+    "start_date" DATE,
+    "due_date" DATE,
+    "estimate_points" INTEGER,
 
     CONSTRAINT "tasks_pkey" PRIMARY KEY ("id"),
-    CONSTRAINT "tasks_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features"("id") ON DELETE RESTRICT ON UPDATE CASCADE
+    CONSTRAINT "tasks_estimate_points_check" CHECK ("estimate_points" >= 0),
+    CONSTRAINT "tasks_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features"("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "tasks_parent_task_id_fkey" FOREIGN KEY ("parent_task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE
 );
 
+CREATE INDEX "tasks_search_vector_idx" ON "tasks" USING GIN ("search_vector");
+CREATE INDEX "tasks_deleted_at_idx" ON "tasks"("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX "tasks_parent_task_id_idx" ON "tasks"("parent_task_id");
+CREATE INDEX "tasks_due_date_idx" ON "tasks"("due_date");
+
 -- CreateTable for FeatureOwners
 CREATE TABLE "feature_owners" (
     "id" UUID NOT NULL DEFAULT gen_random_uuid(),
@@ -54,3 +74,187 @@ CREATE TABLE "feature_owners" (
     CONSTRAINT "feature_owners_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE RESTRICT ON UPDATE CASCADE,
     CONSTRAINT "feature_owners_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features"("id") ON DELETE RESTRICT ON UPDATE CASCADE
 );
+
+CREATE UNIQUE INDEX "feature_owners_feature_id_user_id_key" ON "feature_owners"("feature_id", "user_id");
+
+-- CreateTable for AuditEvents
+-- No foreign keys on purpose: history must survive deleting the entity or the actor.
+CREATE TABLE "audit_events" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "entity_type" TEXT NOT NULL,
+    "entity_id" UUID NOT NULL,
+    "action" TEXT NOT NULL,
+    "actor_id" UUID,
+    "changes" JSONB NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT "audit_events_pkey" PRIMARY KEY ("id")
+);
+
+CREATE INDEX "audit_events_entity_idx" ON "audit_events"("entity_type", "entity_id", "created_at");
+
+-- CreateTable for AgentRuns
+-- A queued request for the coding agent to work on a task. Workers claim rows
+-- with FOR UPDATE SKIP LOCKED; locked_until is the lease of the worker running it.
+CREATE TABLE "agent_runs" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "task_id" UUID NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'queued', -- queued, running, succeeded, failed or cancelled
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "max_attempts" INTEGER NOT NULL,
+    "run_after" TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Not picked up before this, used for retry backoff
+    "locked_until" TIMESTAMPTZ,
+    "provider" TEXT,
+    "model" TEXT,
+    "prompt" TEXT,
+    "output" TEXT,
+    "error" TEXT,
+    "logs" TEXT NOT NULL DEFAULT '',
+    "created_by" UUID,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "started_at" TIMESTAMPTZ,
+    "finished_at" TIMESTAMPTZ,
+
+    CONSTRAINT "agent_runs_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "agent_runs_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "agent_runs_task_id_idx" ON "agent_runs"("task_id", "created_at");
+CREATE INDEX "agent_runs_claim_idx" ON "agent_runs"("run_after") WHERE "status" IN ('queued', 'running');
+
+-- CreateTable for ApiTokens
+-- Personal API tokens. Only the SHA-256 hash of a token is stored; the token
+-- itself is shown once, when it is created.
+CREATE TABLE "api_tokens" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "user_id" UUID NOT NULL,
+    "name" TEXT NOT NULL,
+    "token_hash" TEXT NOT NULL, -- Hex SHA-256 of the token
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "expires_at" TIMESTAMPTZ, -- Never expires when NULL
+    "last_used_at" TIMESTAMPTZ,
+    "revoked_at" TIMESTAMPTZ,
+
+    CONSTRAINT "api_tokens_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "api_tokens_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX "api_tokens_token_hash_key" ON "api_tokens"("token_hash");
+CREATE INDEX "api_tokens_user_id_idx" ON "api_tokens"("user_id");
+
+-- CreateTable for TaskComments
+-- Discussion on a task, in Markdown. Replies point at the comment they answer;
+-- deleting a comment deletes its replies.
+CREATE TABLE "task_comments" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "task_id" UUID NOT NULL,
+    "parent_id" UUID,
+    "author_id" UUID, -- AgentUserID for comments posted by the agent, NULL for system comments
+    "author_name" TEXT NOT NULL, -- Denormalized
+    "agent_run_id" UUID, -- Set on the summaries agent runs post
+    "body" TEXT NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "edited_at" TIMESTAMPTZ, -- Set each time the body is changed
+
+    CONSTRAINT "task_comments_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "task_comments_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "task_comments_parent_id_fkey" FOREIGN KEY ("parent_id") REFERENCES "task_comments"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "task_comments_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "task_comments_agent_run_id_fkey" FOREIGN KEY ("agent_run_id") REFERENCES "agent_runs"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX "task_comments_task_id_idx" ON "task_comments"("task_id", "created_at");
+
+-- CreateTable for TaskDependencies
+-- blocker_id blocks blocked_id: the blocked task can't start or finish while
+-- the blocker is open. Cycles are rejected by the service.
+CREATE TABLE "task_dependencies" (
+    "blocker_id" UUID NOT NULL,
+    "blocked_id" UUID NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT "task_dependencies_pkey" PRIMARY KEY ("blocker_id", "blocked_id"),
+    CONSTRAINT "task_dependencies_not_self" CHECK ("blocker_id" <> "blocked_id"),
+    CONSTRAINT "task_dependencies_blocker_id_fkey" FOREIGN KEY ("blocker_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "task_dependencies_blocked_id_fkey" FOREIGN KEY ("blocked_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "task_dependencies_blocked_id_idx" ON "task_dependencies"("blocked_id");
+
+-- CreateTable for Labels
+-- User-defined categories such as "bug" or "frontend". Names are unique
+-- regardless of case.
+CREATE TABLE "labels" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "name" TEXT NOT NULL,
+    "color" TEXT NOT NULL, -- "#rrggbb"
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "created_by" UUID,
+
+    CONSTRAINT "labels_pkey" PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX "labels_name_key" ON "labels"(lower("name"));
+
+-- CreateTable for TaskLabels
+CREATE TABLE "task_labels" (
+    "task_id" UUID NOT NULL,
+    "label_id" UUID NOT NULL,
+
+    CONSTRAINT "task_labels_pkey" PRIMARY KEY ("task_id", "label_id"),
+    CONSTRAINT "task_labels_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "task_labels_label_id_fkey" FOREIGN KEY ("label_id") REFERENCES "labels"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "task_labels_label_id_idx" ON "task_labels"("label_id");
+
+-- CreateTable for FeatureLabels
+CREATE TABLE "feature_labels" (
+    "feature_id" UUID NOT NULL,
+    "label_id" UUID NOT NULL,
+
+    CONSTRAINT "feature_labels_pkey" PRIMARY KEY ("feature_id", "label_id"),
+    CONSTRAINT "feature_labels_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "feature_labels_label_id_fkey" FOREIGN KEY ("label_id") REFERENCES "labels"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "feature_labels_label_id_idx" ON "feature_labels"("label_id");
+
+-- CreateTable for TaskAssignees
+CREATE TABLE "task_assignees" (
+    "task_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "assigned_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "assigned_by" UUID,
+
+    CONSTRAINT "task_assignees_pkey" PRIMARY KEY ("task_id", "user_id"),
+    CONSTRAINT "task_assignees_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "task_assignees_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "task_assignees_user_id_idx" ON "task_assignees"("user_id");
+
+-- CreateTable for WorkLogs
+-- Time spent on tasks, from timers or entered by hand. A log without ended_at
+-- is a running timer; each user has at most one.
+CREATE TABLE "work_logs" (
+    "id" UUID NOT NULL DEFAULT gen_random_uuid(),
+    "task_id" UUID NOT NULL, -- Tasks with work logs are never purged from the trash
+    "user_id" UUID, -- NULL once the user is deleted
+    "user_name" TEXT NOT NULL, -- Denormalized, so reports keep the name
+    "started_at" TIMESTAMPTZ NOT NULL,
+    "ended_at" TIMESTAMPTZ,
+    "note" TEXT,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT "work_logs_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "work_logs_task_id_fkey" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "work_logs_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "work_logs_ended_after_started" CHECK ("ended_at" IS NULL OR "ended_at" >= "started_at")
+);
+
+CREATE INDEX "work_logs_task_id_idx" ON "work_logs"("task_id", "started_at");
+CREATE INDEX "work_logs_started_at_idx" ON "work_logs"("started_at");
+CREATE UNIQUE INDEX "work_logs_running_timer_key" ON "work_logs"("user_id") WHERE "ended_at" IS NULL;
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"shelke.dev/api/internal/core/domain"
+)
+
+// LoadWorkflow reads a workflow configuration from a JSON file. Sections that
+// are missing from the file fall back to domain.DefaultWorkflow. Configs that
+// fail domain.WorkflowConfig.Validate, such as derive_feature_status with a
+// feature workflow that has no done status, are rejected.
+func LoadWorkflow(path string) (domain.WorkflowConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return domain.WorkflowConfig{}, fmt.Errorf("failed to read workflow config: %w", err)
+	}
+
+	var cfg domain.WorkflowConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return domain.WorkflowConfig{}, fmt.Errorf("failed to parse workflow config: %w", err)
+	}
+	if len(cfg.Task.Statuses) == 0 {
+		cfg.Task = domain.DefaultWorkflow()
+	}
+	if len(cfg.Feature.Statuses) == 0 {
+		cfg.Feature = domain.DefaultWorkflow()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return domain.WorkflowConfig{}, fmt.Errorf("invalid workflow config: %w", err)
+	}
+	return cfg, nil
+}
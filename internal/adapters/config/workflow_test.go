@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"derived feature status with the default feature workflow", `{"derive_feature_status": true}`, false},
+		{
+			name: "derived feature status without done",
+			config: `{"derive_feature_status": true, "feature": {"initial_status": "open", "statuses": ["open", "shipped"],
+				"transitions": {"open": ["shipped"]}, "priorities": ["low", "high"]}}`,
+			wantErr: true,
+		},
+		{
+			name: "feature workflow without done",
+			config: `{"feature": {"initial_status": "open", "statuses": ["open", "shipped"],
+				"transitions": {"open": ["shipped"]}, "priorities": ["low", "high"]}}`,
+		},
+		{
+			name: "task workflow without cancelled",
+			config: `{"task": {"initial_status": "todo", "statuses": ["todo", "in_progress", "done"],
+				"transitions": {"todo": ["in_progress"], "in_progress": ["done"]}, "priorities": ["low", "high"]}}`,
+			wantErr: true,
+		},
+		{"invalid JSON", `{"task":`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadWorkflow(writeConfig(t, tt.config))
+			if tt.wantErr && err == nil {
+				t.Errorf("LoadWorkflow accepted the config: %+v", cfg)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("LoadWorkflow = %v, want nil", err)
+			}
+		})
+	}
+}
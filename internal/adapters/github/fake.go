@@ -0,0 +1,97 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+// Fake is an in-memory ports.PullRequestProvider for tests and local
+// development. Pull requests are numbered per repo starting at 1.
+type Fake struct {
+	mu       sync.Mutex
+	prs      map[string][]ports.PullRequest
+	comments map[string][]ports.ReviewComment
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		prs:      make(map[string][]ports.PullRequest),
+		comments: make(map[string][]ports.ReviewComment),
+	}
+}
+
+func (f *Fake) CreatePullRequest(ctx context.Context, repo domain.GitRepo, pr ports.NewPullRequest) (ports.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.ToLower(repo.FullName())
+	for _, existing := range f.prs[key] {
+		if existing.Head == pr.Head && existing.State == domain.PullRequestOpen {
+			return existing, nil
+		}
+	}
+	base := pr.Base
+	if base == "" {
+		base = repo.DefaultBranch
+	}
+	if base == "" {
+		base = "main"
+	}
+	number := len(f.prs[key]) + 1
+	created := ports.PullRequest{
+		Number: number,
+		URL:    fmt.Sprintf("https://github.com/%s/pull/%d", repo.FullName(), number),
+		State:  domain.PullRequestOpen,
+		Title:  pr.Title,
+		Head:   pr.Head,
+		Base:   base,
+	}
+	f.prs[key] = append(f.prs[key], created)
+	return created, nil
+}
+
+func (f *Fake) GetPullRequest(ctx context.Context, repo domain.GitRepo, number int) (ports.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prs := f.prs[strings.ToLower(repo.FullName())]
+	if number < 1 || number > len(prs) {
+		return ports.PullRequest{}, fmt.Errorf("fake: %s#%d not found", repo.FullName(), number)
+	}
+	return prs[number-1], nil
+}
+
+func (f *Fake) ListReviewComments(ctx context.Context, repo domain.GitRepo, number int) ([]ports.ReviewComment, error) {
+	if _, err := f.GetPullRequest(ctx, repo, number); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ports.ReviewComment(nil), f.comments[commentKey(repo, number)]...), nil
+}
+
+// SetState changes the state of a pull request, e.g. to simulate a merge.
+func (f *Fake) SetState(repo domain.GitRepo, number int, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if prs := f.prs[strings.ToLower(repo.FullName())]; number >= 1 && number <= len(prs) {
+		prs[number-1].State = state
+	}
+}
+
+// AddReviewComment adds a review comment to a pull request.
+func (f *Fake) AddReviewComment(repo domain.GitRepo, number int, comment ports.ReviewComment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := commentKey(repo, number)
+	f.comments[key] = append(f.comments[key], comment)
+}
+
+func commentKey(repo domain.GitRepo, number int) string {
+	return fmt.Sprintf("%s#%d", strings.ToLower(repo.FullName()), number)
+}
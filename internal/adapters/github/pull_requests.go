@@ -0,0 +1,224 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+const DefaultAPIURL = "https://api.github.com"
+
+// Client implements ports.PullRequestProvider with the GitHub REST API.
+type Client struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient returns a GitHub client. An empty baseURL selects DefaultAPIURL;
+// set it for GitHub Enterprise.
+func NewClient(token, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type apiPullRequest struct {
+	Number   int        `json:"number"`
+	HTMLURL  string     `json:"html_url"`
+	State    string     `json:"state"`
+	Title    string     `json:"title"`
+	MergedAt *time.Time `json:"merged_at"`
+	Head     struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (pr apiPullRequest) toPort() ports.PullRequest {
+	state := pr.State
+	if pr.MergedAt != nil {
+		state = domain.PullRequestMerged
+	}
+	return ports.PullRequest{
+		Number: pr.Number,
+		URL:    pr.HTMLURL,
+		State:  state,
+		Title:  pr.Title,
+		Head:   pr.Head.Ref,
+		Base:   pr.Base.Ref,
+	}
+}
+
+type apiReviewComment struct {
+	ID   int64 `json:"id"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Body      string    `json:"body"`
+	Path      string    `json:"path"`
+	Line      *int      `json:"line"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// apiError is returned by GitHub with non-2xx responses.
+type apiError struct {
+	StatusCode int
+	Message    string `json:"message"`
+	Errors     []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *apiError) Error() string {
+	details := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		if detail.Message != "" {
+			details = append(details, detail.Message)
+		}
+	}
+	if len(details) > 0 {
+		return fmt.Sprintf("github: %s (%d): %s", e.Message, e.StatusCode, strings.Join(details, "; "))
+	}
+	return fmt.Sprintf("github: %s (%d)", e.Message, e.StatusCode)
+}
+
+func (c *Client) CreatePullRequest(ctx context.Context, repo domain.GitRepo, pr ports.NewPullRequest) (ports.PullRequest, error) {
+	base := pr.Base
+	if base == "" {
+		base = repo.DefaultBranch
+	}
+	if base == "" {
+		var apiRepo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := c.do(ctx, http.MethodGet, repoPath(repo), nil, &apiRepo); err != nil {
+			return ports.PullRequest{}, err
+		}
+		base = apiRepo.DefaultBranch
+	}
+
+	body := map[string]string{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": base}
+	var created apiPullRequest
+	err := c.do(ctx, http.MethodPost, repoPath(repo)+"/pulls", body, &created)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity && strings.Contains(apiErr.Error(), "already exists") {
+		return c.findOpenPullRequest(ctx, repo, pr.Head)
+	}
+	if err != nil {
+		return ports.PullRequest{}, err
+	}
+	return created.toPort(), nil
+}
+
+func (c *Client) findOpenPullRequest(ctx context.Context, repo domain.GitRepo, head string) (ports.PullRequest, error) {
+	query := url.Values{"state": {"open"}, "head": {repo.Owner + ":" + head}}
+	var open []apiPullRequest
+	if err := c.do(ctx, http.MethodGet, repoPath(repo)+"/pulls?"+query.Encode(), nil, &open); err != nil {
+		return ports.PullRequest{}, err
+	}
+	if len(open) == 0 {
+		return ports.PullRequest{}, fmt.Errorf("github: no open pull request for %s", head)
+	}
+	return open[0].toPort(), nil
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, repo domain.GitRepo, number int) (ports.PullRequest, error) {
+	var pr apiPullRequest
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", repoPath(repo), number), nil, &pr); err != nil {
+		return ports.PullRequest{}, err
+	}
+	return pr.toPort(), nil
+}
+
+func (c *Client) ListReviewComments(ctx context.Context, repo domain.GitRepo, number int) ([]ports.ReviewComment, error) {
+	var comments []ports.ReviewComment
+	for page := 1; ; page++ {
+		var batch []apiReviewComment
+		path := fmt.Sprintf("%s/pulls/%d/comments?per_page=100&page=%d", repoPath(repo), number, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, comment := range batch {
+			line := 0
+			if comment.Line != nil {
+				line = *comment.Line
+			}
+			comments = append(comments, ports.ReviewComment{
+				ID:        comment.ID,
+				Author:    comment.User.Login,
+				Body:      comment.Body,
+				Path:      comment.Path,
+				Line:      line,
+				URL:       comment.HTMLURL,
+				CreatedAt: comment.CreatedAt,
+			})
+		}
+		if len(batch) < 100 {
+			return comments, nil
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("github: failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("github: failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("github: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func repoPath(repo domain.GitRepo) string {
+	return "/repos/" + url.PathEscape(repo.Owner) + "/" + url.PathEscape(repo.Name)
+}
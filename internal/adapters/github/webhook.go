@@ -0,0 +1,123 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+// ErrInvalidSignature is returned when a webhook delivery isn't signed with
+// the configured secret.
+var ErrInvalidSignature = errors.New("github: invalid webhook signature")
+
+// VerifySignature checks the X-Hub-Signature-256 header of a webhook
+// delivery, "sha256=" followed by the hex HMAC-SHA256 of the body.
+func VerifySignature(secret, body []byte, signature string) error {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type webhookRepo struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+		Name  string `json:"name"` // push payloads use name instead of login
+	} `json:"owner"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func (r webhookRepo) toDomain() domain.GitRepo {
+	owner := r.Owner.Login
+	if owner == "" {
+		owner = r.Owner.Name
+	}
+	return domain.GitRepo{Owner: owner, Name: r.Name, URL: r.HTMLURL, DefaultBranch: r.DefaultBranch}
+}
+
+type pullRequestPayload struct {
+	Action      string         `json:"action"`
+	PullRequest apiPullRequest `json:"pull_request"`
+	Repository  webhookRepo    `json:"repository"`
+	Review      struct {
+		State string `json:"state"`
+	} `json:"review"`
+}
+
+type pushPayload struct {
+	Ref        string      `json:"ref"`
+	Deleted    bool        `json:"deleted"`
+	Repository webhookRepo `json:"repository"`
+	Commits    []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"commits"`
+}
+
+// ParseWebhook decodes a webhook delivery of the given X-GitHub-Event type
+// into a ports.PullRequestEvent or ports.PushEvent. Events that don't concern
+// tasks, such as pings or tag pushes, yield nil.
+func ParseWebhook(event string, body []byte) (any, error) {
+	switch event {
+	case "pull_request", "pull_request_review":
+		var payload pullRequestPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("github: invalid %s payload: %w", event, err)
+		}
+		parsed := ports.PullRequestEvent{
+			Action:      payload.Action,
+			Repo:        payload.Repository.toDomain(),
+			PullRequest: payload.PullRequest.toPort(),
+		}
+		if event == "pull_request_review" {
+			// Review states arrive in upper case from some GitHub versions.
+			parsed.ReviewState = strings.ToLower(payload.Review.State)
+		}
+		return parsed, nil
+	case "push":
+		var payload pushPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("github: invalid push payload: %w", err)
+		}
+		branch, ok := strings.CutPrefix(payload.Ref, "refs/heads/")
+		if !ok {
+			return nil, nil
+		}
+		parsed := ports.PushEvent{
+			Repo:    payload.Repository.toDomain(),
+			Branch:  branch,
+			Deleted: payload.Deleted,
+		}
+		for _, commit := range payload.Commits {
+			message, _, _ := strings.Cut(commit.Message, "\n")
+			parsed.Commits = append(parsed.Commits, domain.GitCommit{
+				Repo:    parsed.Repo.FullName(),
+				SHA:     commit.ID,
+				Branch:  branch,
+				Message: message,
+			})
+		}
+		return parsed, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,146 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("webhook secret")
+	body := []byte(`{"action":"opened"}`)
+	valid := sign(secret, body)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{"valid", body, valid, false},
+		{"missing prefix", body, valid[len("sha256="):], true},
+		{"sha1 prefix", body, "sha1=" + valid[len("sha256="):], true},
+		{"empty", body, "", true},
+		{"bad hex", body, "sha256=zz" + valid[len("sha256=")+2:], true},
+		{"truncated", body, valid[:len(valid)-2], true},
+		{"wrong secret", body, sign([]byte("other secret"), body), true},
+		{"other body", []byte(`{"action":"closed"}`), valid, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(secret, tt.body, tt.signature)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature = %v, want ErrInvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifySignature = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		body  string
+		want  any
+	}{
+		{
+			name:  "ping",
+			event: "ping",
+			body:  `{"zen":"Keep it logically awesome."}`,
+			want:  nil,
+		},
+		{
+			name:  "tag push is ignored",
+			event: "push",
+			body:  `{"ref":"refs/tags/v1.0.0","repository":{"name":"api","owner":{"name":"acme"}},"commits":[{"id":"0123abc","message":"Release"}]}`,
+			want:  nil,
+		},
+		{
+			name:  "push uses owner.name",
+			event: "push",
+			body: `{"ref":"refs/heads/task/1","repository":{"name":"api","owner":{"name":"acme"},"html_url":"https://github.com/acme/api","default_branch":"main"},
+				"commits":[{"id":"0123abc","message":"Fix login\n\nLonger description"}]}`,
+			want: ports.PushEvent{
+				Repo:   domain.GitRepo{Owner: "acme", Name: "api", URL: "https://github.com/acme/api", DefaultBranch: "main"},
+				Branch: "task/1",
+				Commits: []domain.GitCommit{
+					{Repo: "acme/api", SHA: "0123abc", Branch: "task/1", Message: "Fix login"},
+				},
+			},
+		},
+		{
+			name:  "branch deleted",
+			event: "push",
+			body:  `{"ref":"refs/heads/task/1","deleted":true,"repository":{"name":"api","owner":{"name":"acme"}},"commits":[]}`,
+			want: ports.PushEvent{
+				Repo:    domain.GitRepo{Owner: "acme", Name: "api"},
+				Branch:  "task/1",
+				Deleted: true,
+			},
+		},
+		{
+			name:  "pull request uses owner.login",
+			event: "pull_request",
+			body: `{"action":"closed","repository":{"name":"api","owner":{"login":"acme","name":"Acme Inc"}},
+				"pull_request":{"number":7,"html_url":"https://github.com/acme/api/pull/7","state":"closed","title":"Fix login","merged_at":"2026-10-18T12:00:00Z","head":{"ref":"task/1"},"base":{"ref":"main"}}}`,
+			want: ports.PullRequestEvent{
+				Action: "closed",
+				Repo:   domain.GitRepo{Owner: "acme", Name: "api"},
+				PullRequest: ports.PullRequest{
+					Number: 7,
+					URL:    "https://github.com/acme/api/pull/7",
+					State:  domain.PullRequestMerged,
+					Title:  "Fix login",
+					Head:   "task/1",
+					Base:   "main",
+				},
+			},
+		},
+		{
+			name:  "review state is lower cased",
+			event: "pull_request_review",
+			body: `{"action":"submitted","review":{"state":"CHANGES_REQUESTED"},"repository":{"name":"api","owner":{"login":"acme"}},
+				"pull_request":{"number":7,"state":"open","head":{"ref":"task/1"},"base":{"ref":"main"}}}`,
+			want: ports.PullRequestEvent{
+				Action:      "submitted",
+				Repo:        domain.GitRepo{Owner: "acme", Name: "api"},
+				PullRequest: ports.PullRequest{Number: 7, State: domain.PullRequestOpen, Head: "task/1", Base: "main"},
+				ReviewState: ports.ReviewChangesRequested,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWebhook(tt.event, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWebhook =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWebhookInvalidPayload(t *testing.T) {
+	for _, event := range []string{"pull_request", "pull_request_review", "push"} {
+		if _, err := ParseWebhook(event, []byte(`{"action":`)); err == nil {
+			t.Errorf("ParseWebhook(%q) accepted a truncated payload", event)
+		}
+	}
+}
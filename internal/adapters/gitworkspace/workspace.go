@@ -0,0 +1,241 @@
+package gitworkspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"shelke.dev/api/internal/core/domain"
+)
+
+// ErrEmptyPatch is returned when applying the diff changes nothing.
+var ErrEmptyPatch = errors.New("patch doesn't change anything")
+
+// Limits on what ReadRepo returns, so prompts built from it stay small
+// enough for the model.
+const (
+	maxListedPaths = 2000
+	maxReadFiles   = 20
+	maxFileSize    = 64 << 10
+)
+
+// Workspace implements ports.RepoWorkspace with the git command line. Every
+// repo gets its own clone under root, which is reused by later calls.
+// Repo URLs must use one of urlSchemes, domain.RepoURLSchemes by default;
+// tests add "file" to work against local bare repositories.
+type Workspace struct {
+	root        string
+	authorName  string
+	authorEmail string
+	urlSchemes  []string
+
+	mu    sync.Mutex
+	repos map[string]*sync.Mutex
+}
+
+func New(root, authorName, authorEmail string) *Workspace {
+	return &Workspace{
+		root:        root,
+		authorName:  authorName,
+		authorEmail: authorEmail,
+		urlSchemes:  domain.RepoURLSchemes,
+		repos:       make(map[string]*sync.Mutex),
+	}
+}
+
+func (w *Workspace) ReadRepo(ctx context.Context, repo domain.GitRepo, branch string, mentions []string) (domain.RepoSnapshot, error) {
+	if err := repo.ValidateWithSchemes(w.urlSchemes); err != nil {
+		return domain.RepoSnapshot{}, err
+	}
+	unlock := w.lock(repo)
+	defer unlock()
+
+	dir, err := w.open(ctx, repo)
+	if err != nil {
+		return domain.RepoSnapshot{}, err
+	}
+	if err := w.checkoutBranch(ctx, dir, repo, branch); err != nil {
+		return domain.RepoSnapshot{}, err
+	}
+	out, err := w.git(ctx, dir, "ls-files", "-z")
+	if err != nil {
+		return domain.RepoSnapshot{}, err
+	}
+	tracked := strings.FieldsFunc(out, func(r rune) bool { return r == 0 })
+
+	snapshot := domain.RepoSnapshot{Repo: repo.FullName(), Branch: branch, Paths: tracked}
+	if len(tracked) > maxListedPaths {
+		snapshot.Paths, snapshot.Truncated = tracked[:maxListedPaths], true
+	}
+	for _, path := range domain.MatchPaths(tracked, mentions) {
+		if len(snapshot.Files) == maxReadFiles {
+			break
+		}
+		content, ok := readTextFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if ok {
+			snapshot.Files = append(snapshot.Files, domain.RepoFile{Path: path, Content: content})
+		}
+	}
+	return snapshot, nil
+}
+
+// readTextFile reads a regular file that isn't binary or too large. Symlinks
+// are skipped, since they may point outside the clone.
+func readTextFile(name string) (string, bool) {
+	info, err := os.Lstat(name)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+		return "", false
+	}
+	content, err := os.ReadFile(name)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return "", false
+	}
+	return string(content), true
+}
+
+func (w *Workspace) CommitPatch(ctx context.Context, repo domain.GitRepo, branch, diff, message string) (string, error) {
+	if err := repo.ValidateWithSchemes(w.urlSchemes); err != nil {
+		return "", err
+	}
+	unlock := w.lock(repo)
+	defer unlock()
+
+	dir, err := w.open(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	if err := w.checkoutBranch(ctx, dir, repo, branch); err != nil {
+		return "", err
+	}
+
+	patch, err := os.CreateTemp("", "agent-*.diff")
+	if err != nil {
+		return "", fmt.Errorf("failed to write patch: %w", err)
+	}
+	defer os.Remove(patch.Name())
+	if !strings.HasSuffix(diff, "\n") {
+		diff += "\n"
+	}
+	if _, err := patch.WriteString(diff); err != nil {
+		patch.Close()
+		return "", fmt.Errorf("failed to write patch: %w", err)
+	}
+	patch.Close()
+
+	if _, err := w.git(ctx, dir, "apply", "--index", "--whitespace=nowarn", patch.Name()); err != nil {
+		return "", fmt.Errorf("failed to apply patch: %w", err)
+	}
+	if _, err := w.git(ctx, dir, "diff", "--cached", "--quiet"); err == nil {
+		return "", ErrEmptyPatch
+	}
+	if _, err := w.git(ctx, dir,
+		"-c", "user.name="+w.authorName, "-c", "user.email="+w.authorEmail,
+		"commit", "--quiet", "-m", message); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	if _, err := w.git(ctx, dir, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", branch, err)
+	}
+	sha, err := w.git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return sha, nil
+}
+
+// lock serializes work on the same clone.
+func (w *Workspace) lock(repo domain.GitRepo) func() {
+	key := strings.ToLower(repo.FullName())
+	w.mu.Lock()
+	m, ok := w.repos[key]
+	if !ok {
+		m = &sync.Mutex{}
+		w.repos[key] = m
+	}
+	w.mu.Unlock()
+	m.Lock()
+	return m.Unlock
+}
+
+// open clones the repo on first use and fetches it afterwards. It returns
+// the clone's directory.
+func (w *Workspace) open(ctx context.Context, repo domain.GitRepo) (string, error) {
+	dir := filepath.Join(w.root, strings.ToLower(repo.Owner), strings.ToLower(repo.Name))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if _, err := w.git(ctx, dir, "remote", "set-url", "--", "origin", cloneURL(repo)); err != nil {
+			return "", err
+		}
+		if _, err := w.git(ctx, dir, "fetch", "--quiet", "--prune", "origin"); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", repo.FullName(), err)
+		}
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	os.RemoveAll(dir) // leftovers of an interrupted clone
+	if _, err := w.git(ctx, filepath.Dir(dir), "clone", "--quiet", "--", cloneURL(repo), dir); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", repo.FullName(), err)
+	}
+	return dir, nil
+}
+
+// checkoutBranch resets the clone to a clean checkout of branch, starting
+// from the remote branch if it exists and from the default branch otherwise.
+func (w *Workspace) checkoutBranch(ctx context.Context, dir string, repo domain.GitRepo, branch string) error {
+	start := "origin/" + branch
+	if _, err := w.git(ctx, dir, "rev-parse", "--verify", "--quiet", start); err != nil {
+		base, err := w.defaultBranch(ctx, dir, repo)
+		if err != nil {
+			return err
+		}
+		start = "origin/" + base
+	}
+	if _, err := w.git(ctx, dir, "checkout", "--quiet", "--force", "-B", branch, start); err != nil {
+		return fmt.Errorf("failed to check out %s: %w", branch, err)
+	}
+	if _, err := w.git(ctx, dir, "clean", "--quiet", "-fdx"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *Workspace) defaultBranch(ctx context.Context, dir string, repo domain.GitRepo) (string, error) {
+	if repo.DefaultBranch != "" {
+		return repo.DefaultBranch, nil
+	}
+	ref, err := w.git(ctx, dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "", fmt.Errorf("%s has no default branch, set default_branch on the repo", repo.FullName())
+	}
+	return strings.TrimPrefix(ref, "origin/"), nil
+}
+
+func (w *Workspace) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// GIT_ALLOW_PROTOCOL also holds for submodules and redirects.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL="+strings.Join(w.urlSchemes, ":"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// cloneURL falls back to GitHub when the repo has no URL.
+func cloneURL(repo domain.GitRepo) string {
+	if repo.URL != "" {
+		return repo.URL
+	}
+	return "https://github.com/" + repo.FullName() + ".git"
+}
@@ -0,0 +1,173 @@
+package gitworkspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shelke.dev/api/internal/core/domain"
+)
+
+const addWorld = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1,2 @@
+ hello
++world
+`
+
+const addBye = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,3 @@
+ hello
+ world
++bye
+`
+
+// noChange applies cleanly but leaves README.md as it is.
+const noChange = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-hello
++hello
+`
+
+// newBareRepo creates a bare repository with README.md on main and returns
+// its path.
+func newBareRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "origin.git")
+	seed := filepath.Join(dir, "seed")
+	runGit(t, dir, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main", seed)
+	if err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, seed, "add", "README.md")
+	runGit(t, seed, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Initial commit")
+	runGit(t, seed, "push", "--quiet", bare, "main")
+	return bare
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func newTestWorkspace(t *testing.T) (*Workspace, domain.GitRepo, string) {
+	bare := newBareRepo(t)
+	w := New(t.TempDir(), "Agent", "agent@example.com")
+	w.urlSchemes = []string{"file"}
+	return w, domain.GitRepo{Owner: "acme", Name: "api", URL: "file://" + bare}, bare
+}
+
+func TestCommitPatch(t *testing.T) {
+	ctx := context.Background()
+	w, repo, bare := newTestWorkspace(t)
+	branch := "task/one"
+
+	sha, err := w.CommitPatch(ctx, repo, branch, addWorld, "Add world")
+	if err != nil {
+		t.Fatalf("first CommitPatch: %v", err)
+	}
+	if got := runGit(t, bare, "rev-parse", "refs/heads/"+branch); got != sha {
+		t.Errorf("pushed %s = %s, want the returned sha %s", branch, got, sha)
+	}
+	if got := runGit(t, bare, "rev-parse", sha+"^"); got != runGit(t, bare, "rev-parse", "refs/heads/main") {
+		t.Errorf("new branch starts at %s, want main", got)
+	}
+	if got := runGit(t, bare, "show", sha+":README.md"); got != "hello\nworld" {
+		t.Errorf("README.md = %q after the patch", got)
+	}
+	if got := runGit(t, bare, "log", "-1", "--format=%an <%ae>|%s", sha); got != "Agent <agent@example.com>|Add world" {
+		t.Errorf("commit = %q", got)
+	}
+
+	// The second commit reuses the clone and builds on the pushed branch.
+	clone := filepath.Join(w.root, "acme", "api")
+	marker := filepath.Join(clone, ".git", "reused")
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.CommitPatch(ctx, repo, branch, addBye, "Add bye")
+	if err != nil {
+		t.Fatalf("second CommitPatch: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("the clone was not reused: %v", err)
+	}
+	if got := runGit(t, bare, "rev-parse", second+"^"); got != sha {
+		t.Errorf("second commit's parent = %s, want %s", got, sha)
+	}
+	if got := runGit(t, bare, "rev-parse", "refs/heads/"+branch); got != second {
+		t.Errorf("pushed %s = %s, want %s", branch, got, second)
+	}
+}
+
+func TestCommitPatchEmpty(t *testing.T) {
+	w, repo, bare := newTestWorkspace(t)
+
+	_, err := w.CommitPatch(context.Background(), repo, "task/empty", noChange, "Nothing")
+	if !errors.Is(err, ErrEmptyPatch) {
+		t.Fatalf("CommitPatch = %v, want ErrEmptyPatch", err)
+	}
+	if refs := runGit(t, bare, "for-each-ref", "refs/heads/task/"); refs != "" {
+		t.Errorf("pushed %s for an empty patch", refs)
+	}
+}
+
+func TestReadRepo(t *testing.T) {
+	ctx := context.Background()
+	w, repo, _ := newTestWorkspace(t)
+	branch := "task/read"
+	if _, err := w.CommitPatch(ctx, repo, branch, addWorld, "Add world"); err != nil {
+		t.Fatalf("CommitPatch: %v", err)
+	}
+
+	snapshot, err := w.ReadRepo(ctx, repo, branch, []string{"README.md", "missing.go"})
+	if err != nil {
+		t.Fatalf("ReadRepo: %v", err)
+	}
+	if snapshot.Repo != "acme/api" || snapshot.Branch != branch || snapshot.Truncated {
+		t.Errorf("snapshot = %+v, want acme/api on %s", snapshot, branch)
+	}
+	if strings.Join(snapshot.Paths, ",") != "README.md" {
+		t.Errorf("paths = %q, want README.md", snapshot.Paths)
+	}
+	want := []domain.RepoFile{{Path: "README.md", Content: "hello\nworld\n"}}
+	if len(snapshot.Files) != 1 || snapshot.Files[0] != want[0] {
+		t.Errorf("files = %+v, want %+v from the task branch", snapshot.Files, want)
+	}
+}
+
+func TestCommitPatchRejectsURLs(t *testing.T) {
+	w := New(t.TempDir(), "Agent", "agent@example.com")
+	for _, url := range []string{
+		"file:///srv/git/api.git",
+		"/srv/git/api.git",
+		"-uupload-pack=touch /tmp/pwned",
+		"ext::sh -c touch% /tmp/pwned",
+		"https:///api.git",
+	} {
+		repo := domain.GitRepo{Owner: "acme", Name: "api", URL: url}
+		if _, err := w.CommitPatch(context.Background(), repo, "task/one", addWorld, "Add world"); err == nil {
+			t.Errorf("CommitPatch accepted url %q", url)
+		}
+	}
+}
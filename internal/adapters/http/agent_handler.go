@@ -0,0 +1,181 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type AgentHandler struct {
+	agentService *services.AgentService
+}
+
+func NewAgentHandler(agentService *services.AgentService) *AgentHandler {
+	return &AgentHandler{agentService: agentService}
+}
+
+// StartAgentRun
+// @Summary Run the coding agent on a task
+// @Description Queue a run that sends the task, its feature and linked repos to the configured LLM. Poll the run to see its progress.
+// @Tags Agent
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 202 {object} AgentRunResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Failed to start agent run"
+// @Router /tasks/{id}/agent-runs [post]
+func (h *AgentHandler) StartAgentRun(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("StartAgentRun: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.agentService.StartRun(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("StartAgentRun: Failed to start agent run: %v\n", err)
+		http.Error(w, "Failed to start agent run", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("StartAgentRun: Agent run queued for task %s: %s\n", taskID.String(), uuid.UUID(run.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toAgentRunResponse(run))
+}
+
+// ListAgentRuns
+// @Summary Get the agent runs of a task
+// @Description Retrieve the task's agent runs with their logs and outputs, newest first
+// @Tags Agent
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} AgentRunResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Failed to list agent runs"
+// @Router /tasks/{id}/agent-runs [get]
+func (h *AgentHandler) ListAgentRuns(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("ListAgentRuns: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := h.agentService.ListRuns(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListAgentRuns: Failed to list agent runs: %v\n", err)
+		http.Error(w, "Failed to list agent runs", http.StatusInternalServerError)
+		return
+	}
+
+	runResponses := make([]AgentRunResponse, len(runs))
+	for i, run := range runs {
+		runResponses[i] = toAgentRunResponse(run)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runResponses)
+}
+
+// GetAgentRun
+// @Summary Get an agent run
+// @Description Retrieve a single agent run with its logs and output
+// @Tags Agent
+// @Produce json
+// @Param id path string true "Agent run ID"
+// @Success 200 {object} AgentRunResponse
+// @Failure 400 {string} string "Invalid agent run ID"
+// @Failure 404 {string} string "Agent run not found"
+// @Failure 500 {string} string "Failed to get agent run"
+// @Router /agent-runs/{id} [get]
+func (h *AgentHandler) GetAgentRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("GetAgentRun: Invalid agent run ID: %v\n", err)
+		http.Error(w, "Invalid agent run ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.agentService.GetRun(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Agent run not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetAgentRun: Failed to get agent run: %v\n", err)
+		http.Error(w, "Failed to get agent run", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toAgentRunResponse(run))
+}
+
+// CancelAgentRun
+// @Summary Cancel an agent run
+// @Description Cancel a queued or running agent run. A running run stops shortly after and its output is discarded.
+// @Tags Agent
+// @Produce json
+// @Param id path string true "Agent run ID"
+// @Success 200 {object} AgentRunResponse
+// @Failure 400 {string} string "Invalid agent run ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Agent run not found"
+// @Failure 409 {string} string "Agent run already finished"
+// @Failure 500 {string} string "Failed to cancel agent run"
+// @Router /agent-runs/{id}/cancel [post]
+func (h *AgentHandler) CancelAgentRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("CancelAgentRun: Invalid agent run ID: %v\n", err)
+		http.Error(w, "Invalid agent run ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.agentService.CancelRun(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Agent run not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CancelAgentRun: Failed to cancel agent run: %v\n", err)
+		http.Error(w, "Failed to cancel agent run", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CancelAgentRun: Agent run cancelled: %s\n", id.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toAgentRunResponse(run))
+}
@@ -0,0 +1,185 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+// GetCurrentUser
+// @Summary Get the authenticated user
+// @Description Retrieve the user the bearer token belongs to
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} UserResponse
+// @Failure 401 {string} string "Missing or invalid bearer token"
+// @Router /auth/me [get]
+func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := services.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+// CreateSession
+// @Summary Create a session token
+// @Description Exchange the bearer token, usually a personal API token, for a signed session token (JWT) that expires after SESSION_TTL
+// @Tags Auth
+// @Produce json
+// @Success 201 {object} SessionResponse
+// @Failure 401 {string} string "Missing or invalid bearer token"
+// @Failure 500 {string} string "Failed to create session"
+// @Router /auth/sessions [post]
+func (h *AuthHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	token, expiresAt, err := h.authService.CreateSession(r.Context())
+	if errors.Is(err, services.ErrUnauthenticated) {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateSession: Failed to create session: %v\n", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(SessionResponse{Token: token, ExpiresAt: expiresAt.Format(time.RFC3339)})
+}
+
+// CreateAPIToken
+// @Summary Create a personal API token
+// @Description Create an API token for the authenticated user. The token is only returned by this call; store it safely.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param token body CreateAPITokenRequest true "API token creation request"
+// @Success 201 {object} CreateAPITokenResponse
+// @Failure 400 {string} string "Invalid request body, name or expiry"
+// @Failure 401 {string} string "Missing or invalid bearer token"
+// @Failure 500 {string} string "Failed to create API token"
+// @Router /auth/tokens [post]
+func (h *AuthHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var reqBody CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		fmt.Printf("CreateAPIToken: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var expiresAt pgt.Timestamptz
+	if reqBody.ExpiresAt != nil {
+		parsed, err := time.Parse(time.RFC3339, *reqBody.ExpiresAt)
+		if err != nil {
+			http.Error(w, "Invalid expires_at, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		expiresAt = pgt.Timestamptz{Time: parsed, Valid: true}
+	}
+
+	apiToken, token, err := h.authService.CreateAPIToken(r.Context(), reqBody.Name, expiresAt)
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrUnauthenticated) {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateAPIToken: Failed to create API token: %v\n", err)
+		http.Error(w, "Failed to create API token", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CreateAPIToken: API token %s created\n", uuid.UUID(apiToken.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateAPITokenResponse{APITokenResponse: toAPITokenResponse(apiToken), Token: token})
+}
+
+// ListAPITokens
+// @Summary Get the authenticated user's API tokens
+// @Description Retrieve the user's personal API tokens, revoked ones included. Secrets are never returned.
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} APITokenResponse
+// @Failure 401 {string} string "Missing or invalid bearer token"
+// @Failure 500 {string} string "Failed to list API tokens"
+// @Router /auth/tokens [get]
+func (h *AuthHandler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.authService.ListAPITokens(r.Context())
+	if errors.Is(err, services.ErrUnauthenticated) {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListAPITokens: Failed to list API tokens: %v\n", err)
+		http.Error(w, "Failed to list API tokens", http.StatusInternalServerError)
+		return
+	}
+
+	tokenResponses := make([]APITokenResponse, len(tokens))
+	for i, token := range tokens {
+		tokenResponses[i] = toAPITokenResponse(token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponses)
+}
+
+// RevokeAPIToken
+// @Summary Revoke an API token
+// @Description Revoke one of the authenticated user's personal API tokens. Requests using it are rejected from then on.
+// @Tags Auth
+// @Param id path string true "API token ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid API token ID"
+// @Failure 401 {string} string "Missing or invalid bearer token"
+// @Failure 404 {string} string "API token not found or already revoked"
+// @Failure 500 {string} string "Failed to revoke API token"
+// @Router /auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RevokeAPIToken: Invalid API token ID: %v\n", err)
+		http.Error(w, "Invalid API token ID", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.authService.RevokeAPIToken(r.Context(), pgt.UUID{Bytes: tokenID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "API token not found or already revoked", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrUnauthenticated) {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RevokeAPIToken: Failed to revoke API token: %v\n", err)
+		http.Error(w, "Failed to revoke API token", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RevokeAPIToken: API token %s revoked\n", tokenID.String())
+	w.WriteHeader(http.StatusNoContent)
+}
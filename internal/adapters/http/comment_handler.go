@@ -0,0 +1,240 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type CommentHandler struct {
+	commentService *services.CommentService
+}
+
+func NewCommentHandler(commentService *services.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: commentService}
+}
+
+// ListTaskComments
+// @Summary Get the discussion on a task
+// @Description Retrieve the task's comments, from people and the agent, as threads with nested replies, oldest first
+// @Tags Comments
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} CommentResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Failed to list comments"
+// @Router /tasks/{id}/comments [get]
+func (h *CommentHandler) ListTaskComments(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("ListTaskComments: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	comments, err := h.commentService.ListComments(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListTaskComments: Failed to list comments: %v\n", err)
+		http.Error(w, "Failed to list comments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCommentThreads(comments))
+}
+
+// CreateTaskComment
+// @Summary Comment on a task
+// @Description Post a Markdown comment on the task as the caller. Set parent_id to reply to another comment on the same task.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param comment body CreateCommentRequest true "Comment request"
+// @Success 201 {object} CommentResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 422 {string} string "Parent comment is not on this task"
+// @Failure 500 {string} string "Failed to create comment"
+// @Router /tasks/{id}/comments [post]
+func (h *CommentHandler) CreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("CreateTaskComment: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody CreateCommentRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("CreateTaskComment: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var parentID pgt.UUID
+	if reqBody.ParentID != nil {
+		id, err := uuid.Parse(*reqBody.ParentID)
+		if err != nil {
+			fmt.Printf("CreateTaskComment: Invalid ParentID: %v\n", err)
+			http.Error(w, "Invalid ParentID format", http.StatusBadRequest)
+			return
+		}
+		parentID = pgt.UUID{Bytes: id, Valid: true}
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, parentID, reqBody.Body)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateTaskComment: Failed to create comment: %v\n", err)
+		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CreateTaskComment: Comment posted on task %s: %s\n", taskID.String(), uuid.UUID(comment.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCommentResponse(comment))
+}
+
+// UpdateTaskComment
+// @Summary Edit a comment
+// @Description Replace the body of a comment, which marks it as edited. Only its author and admins may edit it.
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param commentId path string true "Comment ID"
+// @Param comment body UpdateCommentRequest true "Comment update request"
+// @Success 200 {object} CommentResponse
+// @Failure 400 {string} string "Invalid ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller"
+// @Failure 404 {string} string "Comment not found"
+// @Failure 500 {string} string "Failed to update comment"
+// @Router /tasks/{id}/comments/{commentId} [put]
+func (h *CommentHandler) UpdateTaskComment(w http.ResponseWriter, r *http.Request) {
+	taskID, commentID, ok := parseCommentPath(w, r, "UpdateTaskComment")
+	if !ok {
+		return
+	}
+
+	var reqBody UpdateCommentRequest
+
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("UpdateTaskComment: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(r.Context(), taskID, commentID, reqBody.Body)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("UpdateTaskComment: Failed to update comment: %v\n", err)
+		http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("UpdateTaskComment: Comment updated successfully: %s\n", uuid.UUID(comment.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toCommentResponse(comment))
+}
+
+// DeleteTaskComment
+// @Summary Delete a comment
+// @Description Delete a comment and all replies to it. Only its author and admins may delete it.
+// @Tags Comments
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param commentId path string true "Comment ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 403 {string} string "Not allowed for the caller"
+// @Failure 404 {string} string "Comment not found"
+// @Failure 500 {string} string "Failed to delete comment"
+// @Router /tasks/{id}/comments/{commentId} [delete]
+func (h *CommentHandler) DeleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	taskID, commentID, ok := parseCommentPath(w, r, "DeleteTaskComment")
+	if !ok {
+		return
+	}
+
+	err := h.commentService.DeleteComment(r.Context(), taskID, commentID)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("DeleteTaskComment: Failed to delete comment: %v\n", err)
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("DeleteTaskComment: Comment deleted successfully: %s\n", uuid.UUID(commentID.Bytes).String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseCommentPath reads the task and comment IDs of a
+// /tasks/{id}/comments/{commentId} request, writing a 400 when either is
+// malformed.
+func parseCommentPath(w http.ResponseWriter, r *http.Request, handler string) (taskID, commentID pgt.UUID, ok bool) {
+	task, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("%s: Invalid task ID: %v\n", handler, err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+	comment, err := uuid.Parse(r.PathValue("commentId"))
+	if err != nil {
+		fmt.Printf("%s: Invalid comment ID: %v\n", handler, err)
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+	return pgt.UUID{Bytes: task, Valid: true}, pgt.UUID{Bytes: comment, Valid: true}, true
+}
@@ -2,6 +2,7 @@ package httphandler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,14 +11,16 @@ import (
 	pgt "github.com/jackc/pgx/v5/pgtype"
 	db "shelke.dev/api/db/sqlc"
 	"shelke.dev/api/internal/core/services"
+	"shelke.dev/api/internal/ports"
 )
 
 type FeatureHandler struct {
 	featureService *services.FeatureService
+	userService    *services.UserService
 }
 
-func NewFeatureHandler(featureService *services.FeatureService) *FeatureHandler {
-	return &FeatureHandler{featureService: featureService}
+func NewFeatureHandler(featureService *services.FeatureService, userService *services.UserService) *FeatureHandler {
+	return &FeatureHandler{featureService: featureService, userService: userService}
 }
 
 // CreateFeature
@@ -29,6 +32,8 @@ func NewFeatureHandler(featureService *services.FeatureService) *FeatureHandler
 // @Param feature body CreateFeatureRequest true "Feature creation request"
 // @Success 201 {object} FeatureResponse
 // @Failure 400 {string} string "Invalid request body or format"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 422 {string} string "Unknown status or priority"
 // @Failure 500 {string} string "Failed to create feature"
 // @Router /features [post]
 func (h *FeatureHandler) CreateFeature(w http.ResponseWriter, r *http.Request) {
@@ -50,23 +55,29 @@ func (h *FeatureHandler) CreateFeature(w http.ResponseWriter, r *http.Request) {
 	if reqBody.Description != nil {
 		arg.Description = pgt.Text{String: *reqBody.Description, Valid: true}
 	}
-	if reqBody.CreatedBy != nil {
-		createdByUUID, err := uuid.Parse(*reqBody.CreatedBy)
-		if err != nil {
-			fmt.Printf("CreateFeature: Invalid CreatedBy UUID: %v\n", err)
-			http.Error(w, "Invalid CreatedBy UUID format", http.StatusBadRequest)
-			return
-		}
-		arg.CreatedBy = pgt.UUID{Bytes: createdByUUID, Valid: true}
-	}
 	if reqBody.Priority != nil {
 		arg.Priority = pgt.Text{String: *reqBody.Priority, Valid: true}
 	}
 	if reqBody.Status != nil {
 		arg.Status = pgt.Text{String: *reqBody.Status, Valid: true}
 	}
+	if reqBody.Repos != nil {
+		arg.Repos = reqBody.Repos
+	}
+	if arg.TargetDate, err = parseDate("target_date", reqBody.TargetDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	feature, err := h.featureService.CreateFeature(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		fmt.Printf("CreateFeature: Failed to create feature: %v\n", err)
 		http.Error(w, "Failed to create feature", http.StatusInternalServerError)
@@ -77,22 +88,71 @@ func (h *FeatureHandler) CreateFeature(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(toFeatureResponse(feature))
+	json.NewEncoder(w).Encode(toFeatureResponse(feature, services.FeatureDetails{}))
 }
 
 
 // ListFeatures
-// @Summary Get all features
-// @Description Retrieve a list of all features
+// @Summary Get features
+// @Description Retrieve a filtered, sorted page of features, each with the progress of its tasks. The features are in items; pass next_cursor as cursor to get the next page, it is null on the last one. Before pagination this returned a bare array of features.
 // @Tags Features
 // @Produce json
-// @Success 200 {array} FeatureResponse
+// @Param status query string false "Only features with this status"
+// @Param priority query string false "Only features with this priority"
+// @Param created_by query string false "Only features created by this user"
+// @Param created_after query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_after query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_before query string false "Updated before (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "Sort column: created_at, updated_at, name, priority or status. priority and status sort alphabetically, not in workflow order"
+// @Param order query string false "asc or desc"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Page size (max 200)"
+// @Success 200 {object} FeatureListResponse
+// @Failure 400 {string} string "Invalid query parameters"
 // @Failure 500 {string} string "Failed to list features"
 // @Router /features [get]
 func (h *FeatureHandler) ListFeatures(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	arg := db.ListFeaturesParams{
+		Status:   parseTextQuery(query, "status"),
+		Priority: parseTextQuery(query, "priority"),
+	}
+	var err error
+	if arg.CreatedBy, err = parseUUIDQuery(query, "created_by"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.CreatedAfter, err = parseTimeQuery(query, "created_after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.CreatedBefore, err = parseTimeQuery(query, "created_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.UpdatedAfter, err = parseTimeQuery(query, "updated_after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.UpdatedBefore, err = parseTimeQuery(query, "updated_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parsePageRequest(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	fmt.Println("ListFeatures: Calling service to list features")
 
-	features, err := h.featureService.ListFeatures(r.Context())
+	features, nextCursor, err := h.featureService.ListFeatures(r.Context(), arg, page)
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		fmt.Printf("ListFeatures: Failed to list features: %v\n", err)
 		http.Error(w, "Failed to list features", http.StatusInternalServerError)
@@ -101,13 +161,73 @@ func (h *FeatureHandler) ListFeatures(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("ListFeatures: Successfully listed %d features\n", len(features))
 
-	featureResponses := make([]FeatureResponse, len(features))
+	featureIDs := make([]pgt.UUID, len(features))
+	for i, feature := range features {
+		featureIDs[i] = feature.ID
+	}
+	details, err := h.featureService.ListFeatureDetails(r.Context(), featureIDs)
+	if err != nil {
+		fmt.Printf("ListFeatures: Failed to load feature details: %v\n", err)
+		http.Error(w, "Failed to list features", http.StatusInternalServerError)
+		return
+	}
+
+	response := FeatureListResponse{Items: make([]FeatureResponse, len(features))}
 	for i, feature := range features {
-		featureResponses[i] = toFeatureResponse(feature)
+		response.Items[i] = toFeatureResponse(feature, details[feature.ID])
+	}
+	if nextCursor != "" {
+		response.NextCursor = &nextCursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetFeature
+// @Summary Get a feature
+// @Description Retrieve a single feature by its ID, with the progress of its tasks
+// @Tags Features
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Success 200 {object} FeatureResponse
+// @Failure 400 {string} string "Invalid feature ID"
+// @Failure 404 {string} string "Feature not found"
+// @Failure 500 {string} string "Failed to get feature"
+// @Router /features/{id} [get]
+func (h *FeatureHandler) GetFeature(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/features/")
+	if idStr == "" {
+		http.Error(w, "Feature ID is required", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		fmt.Printf("GetFeature: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+
+	feature, err := h.featureService.GetFeature(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetFeature: Failed to get feature: %v\n", err)
+		http.Error(w, "Failed to get feature", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.featureService.GetFeatureDetails(r.Context(), feature.ID)
+	if err != nil {
+		fmt.Printf("GetFeature: Failed to load feature details: %v\n", err)
+		http.Error(w, "Failed to get feature", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(featureResponses)
+	json.NewEncoder(w).Encode(toFeatureResponse(feature, details))
 }
 
 // UpdateFeature
@@ -120,6 +240,10 @@ func (h *FeatureHandler) ListFeatures(w http.ResponseWriter, r *http.Request) {
 // @Param feature body UpdateFeatureRequest true "Feature update request"
 // @Success 200 {object} FeatureResponse
 // @Failure 400 {string} string "Invalid feature ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Feature not found"
+// @Failure 409 {string} string "Status transition not allowed"
+// @Failure 422 {string} string "Unknown status or priority"
 // @Failure 500 {string} string "Failed to update feature"
 // @Router /features/{id} [put]
 func (h *FeatureHandler) UpdateFeature(w http.ResponseWriter, r *http.Request) {
@@ -163,8 +287,34 @@ func (h *FeatureHandler) UpdateFeature(w http.ResponseWriter, r *http.Request) {
 	if reqBody.Status != nil {
 		arg.Status = pgt.Text{String: *reqBody.Status, Valid: true}
 	}
+	if reqBody.Repos != nil {
+		arg.Repos = reqBody.Repos
+	}
+	if reqBody.TargetDate.Set {
+		arg.SetTargetDate = true
+		if arg.TargetDate, err = parseDate("target_date", reqBody.TargetDate.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	feature, err := h.featureService.UpdateFeature(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		fmt.Printf("UpdateFeature: Failed to update feature: %v\n", err)
 		http.Error(w, "Failed to update feature", http.StatusInternalServerError)
@@ -173,19 +323,31 @@ func (h *FeatureHandler) UpdateFeature(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("UpdateFeature: Feature updated successfully: %+v\n", feature)
 
+	details, err := h.featureService.GetFeatureDetails(r.Context(), feature.ID)
+	if err != nil {
+		fmt.Printf("UpdateFeature: Failed to load feature details: %v\n", err)
+		http.Error(w, "Failed to update feature", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(toFeatureResponse(feature))
+	json.NewEncoder(w).Encode(toFeatureResponse(feature, details))
 }
 
 
 // DeleteFeature
 // @Summary Delete a feature
-// @Description Delete a feature by its ID
+// @Description Move a feature to the trash. A feature that still has tasks is only deleted with mode=cascade, which moves its tasks to the trash too, or mode=reassign, which moves them to the feature given in "to".
 // @Tags Features
 // @Produce json
 // @Param id path string true "Feature ID"
+// @Param mode query string false "What to do with the feature's tasks" Enums(cascade, reassign)
+// @Param to query string false "Feature ID the tasks move to when mode=reassign"
 // @Success 204 "No Content"
-// @Failure 400 {string} string "Invalid feature ID"
+// @Failure 400 {string} string "Invalid feature ID or delete mode"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Feature not found"
+// @Failure 409 {object} FeatureDeleteConflictResponse
 // @Failure 500 {string} string "Failed to delete feature"
 // @Router /features/{id} [delete]
 func (h *FeatureHandler) DeleteFeature(w http.ResponseWriter, r *http.Request) {
@@ -201,7 +363,41 @@ func (h *FeatureHandler) DeleteFeature(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.featureService.DeleteFeature(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	query := r.URL.Query()
+	opts := ports.DeleteFeatureOptions{Mode: ports.FeatureDeleteMode(query.Get("mode"))}
+	if opts.ReassignTo, err = parseUUIDQuery(query, "to"); err != nil {
+		fmt.Printf("DeleteFeature: Invalid target feature ID: %v\n", err)
+		http.Error(w, "Invalid target feature ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.featureService.DeleteFeature(r.Context(), pgt.UUID{Bytes: id, Valid: true}, opts)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var hasTasks *services.FeatureHasTasksError
+	if errors.As(err, &hasTasks) {
+		response := FeatureDeleteConflictResponse{
+			Error:   "Feature still has tasks; delete with mode=cascade or mode=reassign",
+			TaskIDs: make([]string, len(hasTasks.TaskIDs)),
+		}
+		for i, taskID := range hasTasks.TaskIDs {
+			response.TaskIDs[i] = uuid.UUID(taskID.Bytes).String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
 	if err != nil {
 		fmt.Printf("DeleteFeature: Failed to delete feature: %v\n", err)
 		http.Error(w, "Failed to delete feature", http.StatusInternalServerError)
@@ -0,0 +1,148 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+// ListFeatureOwners
+// @Summary Get the owners of a feature
+// @Description Retrieve all users that own the given feature
+// @Tags Features
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Success 200 {array} FeatureOwnerResponse
+// @Failure 400 {string} string "Invalid feature ID"
+// @Failure 500 {string} string "Failed to list feature owners"
+// @Router /features/{id}/owners [get]
+func (h *FeatureHandler) ListFeatureOwners(w http.ResponseWriter, r *http.Request) {
+	featureID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("ListFeatureOwners: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+
+	owners, err := h.featureService.ListFeatureOwners(r.Context(), pgt.UUID{Bytes: featureID, Valid: true})
+	if err != nil {
+		fmt.Printf("ListFeatureOwners: Failed to list feature owners: %v\n", err)
+		http.Error(w, "Failed to list feature owners", http.StatusInternalServerError)
+		return
+	}
+
+	ownerResponses := make([]FeatureOwnerResponse, len(owners))
+	for i, owner := range owners {
+		ownerResponses[i] = toFeatureOwnerResponse(owner)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ownerResponses)
+}
+
+// AddFeatureOwner
+// @Summary Add an owner to a feature
+// @Description Make an existing user an owner of the feature
+// @Tags Features
+// @Accept json
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Param owner body AddFeatureOwnerRequest true "Feature owner request"
+// @Success 201 {object} FeatureOwnerResponse
+// @Failure 400 {string} string "Invalid feature ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Feature or user not found"
+// @Failure 500 {string} string "Failed to add feature owner"
+// @Router /features/{id}/owners [post]
+func (h *FeatureHandler) AddFeatureOwner(w http.ResponseWriter, r *http.Request) {
+	featureID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("AddFeatureOwner: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody AddFeatureOwnerRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("AddFeatureOwner: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := uuid.Parse(reqBody.UserID)
+	if err != nil {
+		fmt.Printf("AddFeatureOwner: Invalid UserID: %v\n", err)
+		http.Error(w, "Invalid UserID format", http.StatusBadRequest)
+		return
+	}
+
+	owner, err := h.featureService.AddFeatureOwner(r.Context(), pgt.UUID{Bytes: featureID, Valid: true}, pgt.UUID{Bytes: userID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature or user not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("AddFeatureOwner: Failed to add feature owner: %v\n", err)
+		http.Error(w, "Failed to add feature owner", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("AddFeatureOwner: Feature owner added successfully: %+v\n", owner)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toFeatureOwnerResponse(owner))
+}
+
+// RemoveFeatureOwner
+// @Summary Remove an owner from a feature
+// @Description Remove a user from the owners of the feature
+// @Tags Features
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Param userId path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid feature ID or user ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 500 {string} string "Failed to remove feature owner"
+// @Router /features/{id}/owners/{userId} [delete]
+func (h *FeatureHandler) RemoveFeatureOwner(w http.ResponseWriter, r *http.Request) {
+	featureID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RemoveFeatureOwner: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+	userID, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		fmt.Printf("RemoveFeatureOwner: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.featureService.RemoveFeatureOwner(r.Context(), pgt.UUID{Bytes: featureID, Valid: true}, pgt.UUID{Bytes: userID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RemoveFeatureOwner: Failed to remove feature owner: %v\n", err)
+		http.Error(w, "Failed to remove feature owner", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RemoveFeatureOwner: Feature owner removed successfully: %s\n", userID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type HistoryHandler struct {
+	auditService *services.AuditService
+}
+
+func NewHistoryHandler(auditService *services.AuditService) *HistoryHandler {
+	return &HistoryHandler{auditService: auditService}
+}
+
+// TaskHistory
+// @Summary Get the change history of a task
+// @Description Retrieve every create, update and delete of the task with field-level before/after values, newest first
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} AuditEventResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 500 {string} string "Failed to get task history"
+// @Router /tasks/{id}/history [get]
+func (h *HistoryHandler) TaskHistory(w http.ResponseWriter, r *http.Request) {
+	h.history(w, r, services.AuditEntityTask, "task")
+}
+
+// FeatureHistory
+// @Summary Get the change history of a feature
+// @Description Retrieve every create, update, delete and owner change of the feature with field-level before/after values, newest first
+// @Tags Features
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Success 200 {array} AuditEventResponse
+// @Failure 400 {string} string "Invalid feature ID"
+// @Failure 500 {string} string "Failed to get feature history"
+// @Router /features/{id}/history [get]
+func (h *HistoryHandler) FeatureHistory(w http.ResponseWriter, r *http.Request) {
+	h.history(w, r, services.AuditEntityFeature, "feature")
+}
+
+func (h *HistoryHandler) history(w http.ResponseWriter, r *http.Request, entityType string, label string) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("History: Invalid %s ID: %v\n", label, err)
+		http.Error(w, fmt.Sprintf("Invalid %s ID", label), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.auditService.ListHistory(r.Context(), entityType, pgt.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		fmt.Printf("History: Failed to get %s history: %v\n", label, err)
+		http.Error(w, fmt.Sprintf("Failed to get %s history", label), http.StatusInternalServerError)
+		return
+	}
+
+	eventResponses := make([]AuditEventResponse, len(events))
+	for i, event := range events {
+		eventResponses[i] = toAuditEventResponse(event)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(eventResponses)
+}
@@ -0,0 +1,366 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/services"
+)
+
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelHandler(labelService *services.LabelService) *LabelHandler {
+	return &LabelHandler{labelService: labelService}
+}
+
+// ListLabels
+// @Summary List labels
+// @Description Retrieve all labels, sorted by name
+// @Tags Labels
+// @Produce json
+// @Success 200 {array} LabelResponse
+// @Failure 500 {string} string "Failed to list labels"
+// @Router /labels [get]
+func (h *LabelHandler) ListLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := h.labelService.ListLabels(r.Context())
+	if err != nil {
+		fmt.Printf("ListLabels: Failed to list labels: %v\n", err)
+		http.Error(w, "Failed to list labels", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toLabelResponses(labels))
+}
+
+// CreateLabel
+// @Summary Create a label
+// @Description Create a label to attach to tasks and features. Names are unique regardless of case.
+// @Tags Labels
+// @Accept json
+// @Produce json
+// @Param label body CreateLabelRequest true "Label request"
+// @Success 201 {object} LabelResponse
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 409 {string} string "A label with this name already exists"
+// @Failure 422 {string} string "Invalid name or color"
+// @Failure 500 {string} string "Failed to create label"
+// @Router /labels [post]
+func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	var reqBody CreateLabelRequest
+
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("CreateLabel: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	label, err := h.labelService.CreateLabel(r.Context(), db.CreateLabelParams{
+		Name:  reqBody.Name,
+		Color: reqBody.Color,
+	})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateLabel: Failed to create label: %v\n", err)
+		http.Error(w, "Failed to create label", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CreateLabel: Label created successfully: %s\n", uuid.UUID(label.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toLabelResponse(label))
+}
+
+// UpdateLabel
+// @Summary Update a label
+// @Description Rename or recolor a label. The change shows on every task and feature it is attached to.
+// @Tags Labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Label ID"
+// @Param label body UpdateLabelRequest true "Label update request"
+// @Success 200 {object} LabelResponse
+// @Failure 400 {string} string "Invalid label ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Label not found"
+// @Failure 409 {string} string "A label with this name already exists"
+// @Failure 422 {string} string "Invalid name or color"
+// @Failure 500 {string} string "Failed to update label"
+// @Router /labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("UpdateLabel: Invalid label ID: %v\n", err)
+		http.Error(w, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody UpdateLabelRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("UpdateLabel: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	arg := db.UpdateLabelParams{ID: pgt.UUID{Bytes: id, Valid: true}}
+	if reqBody.Name != nil {
+		arg.Name = pgt.Text{String: *reqBody.Name, Valid: true}
+	}
+	if reqBody.Color != nil {
+		arg.Color = pgt.Text{String: *reqBody.Color, Valid: true}
+	}
+
+	label, err := h.labelService.UpdateLabel(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Label not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("UpdateLabel: Failed to update label: %v\n", err)
+		http.Error(w, "Failed to update label", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("UpdateLabel: Label updated successfully: %s\n", id.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toLabelResponse(label))
+}
+
+// DeleteLabel
+// @Summary Delete a label
+// @Description Delete a label and remove it from every task and feature. Admins only.
+// @Tags Labels
+// @Param id path string true "Label ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid label ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Label not found"
+// @Failure 500 {string} string "Failed to delete label"
+// @Router /labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("DeleteLabel: Invalid label ID: %v\n", err)
+		http.Error(w, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.labelService.DeleteLabel(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Label not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("DeleteLabel: Failed to delete label: %v\n", err)
+		http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("DeleteLabel: Label deleted successfully: %s\n", id.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddTaskLabel
+// @Summary Label a task
+// @Description Attach a label to the task. Attaching a label the task already has is a no-op.
+// @Tags Labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param label body AttachLabelRequest true "Label to attach"
+// @Success 200 {array} LabelResponse "The task's labels"
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or label not found"
+// @Failure 500 {string} string "Failed to label task"
+// @Router /tasks/{id}/labels [post]
+func (h *LabelHandler) AddTaskLabel(w http.ResponseWriter, r *http.Request) {
+	taskID, labelID, ok := parseAttachLabelRequest(w, r, "AddTaskLabel", "task")
+	if !ok {
+		return
+	}
+
+	labels, err := h.labelService.AttachTaskLabel(r.Context(), taskID, labelID)
+	writeLabelsResult(w, "AddTaskLabel", "Failed to label task", labels, err)
+}
+
+// RemoveTaskLabel
+// @Summary Unlabel a task
+// @Description Detach a label from the task
+// @Tags Labels
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param labelId path string true "Label ID"
+// @Success 200 {array} LabelResponse "The task's remaining labels"
+// @Failure 400 {string} string "Invalid task or label ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Label not found or not on the task"
+// @Failure 500 {string} string "Failed to unlabel task"
+// @Router /tasks/{id}/labels/{labelId} [delete]
+func (h *LabelHandler) RemoveTaskLabel(w http.ResponseWriter, r *http.Request) {
+	taskID, labelID, ok := parseLabelPath(w, r, "RemoveTaskLabel", "task")
+	if !ok {
+		return
+	}
+
+	labels, err := h.labelService.DetachTaskLabel(r.Context(), taskID, labelID)
+	writeLabelsResult(w, "RemoveTaskLabel", "Failed to unlabel task", labels, err)
+}
+
+// AddFeatureLabel
+// @Summary Label a feature
+// @Description Attach a label to the feature. Only the feature's owners and admins may label it.
+// @Tags Labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Param label body AttachLabelRequest true "Label to attach"
+// @Success 200 {array} LabelResponse "The feature's labels"
+// @Failure 400 {string} string "Invalid feature ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller"
+// @Failure 404 {string} string "Feature or label not found"
+// @Failure 500 {string} string "Failed to label feature"
+// @Router /features/{id}/labels [post]
+func (h *LabelHandler) AddFeatureLabel(w http.ResponseWriter, r *http.Request) {
+	featureID, labelID, ok := parseAttachLabelRequest(w, r, "AddFeatureLabel", "feature")
+	if !ok {
+		return
+	}
+
+	labels, err := h.labelService.AttachFeatureLabel(r.Context(), featureID, labelID)
+	writeLabelsResult(w, "AddFeatureLabel", "Failed to label feature", labels, err)
+}
+
+// RemoveFeatureLabel
+// @Summary Unlabel a feature
+// @Description Detach a label from the feature. Only the feature's owners and admins may unlabel it.
+// @Tags Labels
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Param labelId path string true "Label ID"
+// @Success 200 {array} LabelResponse "The feature's remaining labels"
+// @Failure 400 {string} string "Invalid feature or label ID"
+// @Failure 403 {string} string "Not allowed for the caller"
+// @Failure 404 {string} string "Label not found or not on the feature"
+// @Failure 500 {string} string "Failed to unlabel feature"
+// @Router /features/{id}/labels/{labelId} [delete]
+func (h *LabelHandler) RemoveFeatureLabel(w http.ResponseWriter, r *http.Request) {
+	featureID, labelID, ok := parseLabelPath(w, r, "RemoveFeatureLabel", "feature")
+	if !ok {
+		return
+	}
+
+	labels, err := h.labelService.DetachFeatureLabel(r.Context(), featureID, labelID)
+	writeLabelsResult(w, "RemoveFeatureLabel", "Failed to unlabel feature", labels, err)
+}
+
+// parseAttachLabelRequest reads the ID of the labelled task or feature from
+// the path and the label ID from the body, writing a 400 when either is
+// malformed.
+func parseAttachLabelRequest(w http.ResponseWriter, r *http.Request, handler, entity string) (entityID, labelID pgt.UUID, ok bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("%s: Invalid %s ID: %v\n", handler, entity, err)
+		http.Error(w, fmt.Sprintf("Invalid %s ID", entity), http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+
+	var reqBody AttachLabelRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("%s: Invalid request body: %v\n", handler, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+
+	label, err := uuid.Parse(reqBody.LabelID)
+	if err != nil {
+		fmt.Printf("%s: Invalid LabelID: %v\n", handler, err)
+		http.Error(w, "Invalid LabelID format", http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+	return pgt.UUID{Bytes: id, Valid: true}, pgt.UUID{Bytes: label, Valid: true}, true
+}
+
+// parseLabelPath reads the task or feature and label IDs of a
+// /{entity}s/{id}/labels/{labelId} request, writing a 400 when either is
+// malformed.
+func parseLabelPath(w http.ResponseWriter, r *http.Request, handler, entity string) (entityID, labelID pgt.UUID, ok bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("%s: Invalid %s ID: %v\n", handler, entity, err)
+		http.Error(w, fmt.Sprintf("Invalid %s ID", entity), http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+	label, err := uuid.Parse(r.PathValue("labelId"))
+	if err != nil {
+		fmt.Printf("%s: Invalid label ID: %v\n", handler, err)
+		http.Error(w, "Invalid label ID", http.StatusBadRequest)
+		return pgt.UUID{}, pgt.UUID{}, false
+	}
+	return pgt.UUID{Bytes: id, Valid: true}, pgt.UUID{Bytes: label, Valid: true}, true
+}
+
+// writeLabelsResult writes the labels of a task or feature after one was
+// attached or detached, or the error that prevented it.
+func writeLabelsResult(w http.ResponseWriter, handler, failure string, labels []db.Label, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s: %s: %v\n", handler, failure, err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toLabelResponses(labels))
+}
@@ -6,65 +6,440 @@ import (
 
 // CreateTaskRequest represents the request body for creating a new task.
 type CreateTaskRequest struct {
-	Name        string          `json:"name"`
-	Description *string         `json:"description"`
-	CreatedBy   *string         `json:"created_by"`
-	FeatureID   *string         `json:"feature_id"`
-	Priority    *string         `json:"priority"`
-	Status      *string         `json:"status"`
-	GitData     json.RawMessage `json:"git_data"`
+	Name           string          `json:"name"`
+	Description    *string         `json:"description"`
+	FeatureID      *string         `json:"feature_id"`
+	Priority       *string         `json:"priority"`
+	Status         *string         `json:"status"`
+	GitData        json.RawMessage `json:"git_data" swaggertype:"object"`   // see domain.GitData
+	ParentTaskID   *string         `json:"parent_task_id"`                  // Makes the task a subtask; feature_id defaults to the parent's
+	StartDate      *string         `json:"start_date" example:"2026-11-02"` // YYYY-MM-DD
+	DueDate        *string         `json:"due_date" example:"2026-11-13"`   // YYYY-MM-DD
+	EstimatePoints *int32          `json:"estimate_points" example:"3"`
 }
 
 // UpdateTaskRequest represents the request body for updating an existing task.
 type UpdateTaskRequest struct {
-	Name        *string         `json:"name"`
-	Description *string         `json:"description"`
-	FeatureID   *string         `json:"feature_id"`
-	Priority    *string         `json:"priority"`
-	Status      *string         `json:"status"`
-	GitData     json.RawMessage `json:"git_data"`
+	Name           *string          `json:"name"`
+	Description    *string          `json:"description"`
+	FeatureID      *string          `json:"feature_id"`
+	Priority       *string          `json:"priority"`
+	Status         *string          `json:"status"`
+	GitData        json.RawMessage  `json:"git_data" swaggertype:"object"`                        // see domain.GitData, replaces the stored git data
+	StartDate      Nullable[string] `json:"start_date" swaggertype:"string" example:"2026-11-02"` // YYYY-MM-DD, null clears it
+	DueDate        Nullable[string] `json:"due_date" swaggertype:"string" example:"2026-11-13"`   // YYYY-MM-DD, null clears it
+	EstimatePoints Nullable[int32]  `json:"estimate_points" swaggertype:"integer" example:"3"`    // null clears it
 }
 
 // CreateFeatureRequest represents the request body for creating a new feature.
 type CreateFeatureRequest struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	CreatedBy   *string `json:"created_by"`
-	Priority    *string `json:"priority"`
-	Status      *string `json:"status"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description"`
+	Priority    *string         `json:"priority"`
+	Status      *string         `json:"status"`
+	Repos       json.RawMessage `json:"repos" swaggertype:"array,object"` // list of domain.GitRepo
+	TargetDate  *string         `json:"target_date" example:"2026-12-01"` // YYYY-MM-DD
 }
 
 // UpdateFeatureRequest represents the request body for updating an existing feature.
 type UpdateFeatureRequest struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	Priority    *string `json:"priority"`
-	Status      *string `json:"status"`
+	Name        string           `json:"name"`
+	Description *string          `json:"description"`
+	Priority    *string          `json:"priority"`
+	Status      *string          `json:"status"`
+	Repos       json.RawMessage  `json:"repos" swaggertype:"array,object"`                      // list of domain.GitRepo, kept when omitted
+	TargetDate  Nullable[string] `json:"target_date" swaggertype:"string" example:"2026-12-01"` // YYYY-MM-DD, kept when omitted, null clears it
+}
+
+// CreateUserRequest represents the request body for creating a new user.
+type CreateUserRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// UpdateUserRequest represents the request body for updating an existing user.
+type UpdateUserRequest struct {
+	Name *string `json:"name"`
+	Role *string `json:"role"`
+}
+
+// AddFeatureOwnerRequest represents the request body for adding an owner to a feature.
+type AddFeatureOwnerRequest struct {
+	UserID string `json:"user_id"`
 }
 
 // FeatureResponse represents the HTTP response for a feature.
 type FeatureResponse struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description *string                 `json:"description,omitempty"`
+	CreatedAt   string                  `json:"created_at"`
+	UpdatedAt   string                  `json:"updated_at"`
+	CreatedBy   *string                 `json:"created_by,omitempty"`
+	Priority    *string                 `json:"priority,omitempty"`
+	Status      *string                 `json:"status,omitempty"`
+	DeletedAt   *string                 `json:"deleted_at,omitempty"`
+	Repos       json.RawMessage         `json:"repos" swaggertype:"array,object"`
+	TargetDate  *string                 `json:"target_date,omitempty"` // YYYY-MM-DD
+	Owners      []FeatureOwnerResponse  `json:"owners"`
+	Labels      []LabelResponse         `json:"labels"`
+	Progress    FeatureProgressResponse `json:"progress"`
+}
+
+// FeatureProgressResponse counts a feature's tasks, subtasks included. Cancelled
+// tasks are left out of percent_done and of the points.
+type FeatureProgressResponse struct {
+	TotalTasks      int64            `json:"total_tasks"`
+	ByStatus        map[string]int64 `json:"by_status"`
+	PercentDone     int              `json:"percent_done"`
+	PointsDone      int64            `json:"points_done"`
+	PointsRemaining int64            `json:"points_remaining"`
+}
+
+// FeatureOwnerResponse represents the HTTP response for a feature owner.
+type FeatureOwnerResponse struct {
+	ID        string  `json:"id"`
+	FeatureID string  `json:"feature_id"`
+	UserID    string  `json:"user_id"`
+	UserName  *string `json:"user_name,omitempty"`
+	UserRole  *string `json:"user_role,omitempty"`
+}
+
+// TaskResponse represents the HTTP response for a task.
+type TaskResponse struct {
+	ID              string                   `json:"id"`
+	Name            string                   `json:"name"`
+	Description     *string                  `json:"description,omitempty"`
+	CreatedAt       string                   `json:"created_at"`
+	UpdatedAt       string                   `json:"updated_at"`
+	CreatedBy       *string                  `json:"created_by,omitempty"`
+	FeatureID       string                   `json:"feature_id"`
+	FeatureName     *string                  `json:"feature_name,omitempty"`
+	Priority        *string                  `json:"priority,omitempty"`
+	Status          *string                  `json:"status,omitempty"`
+	GitData         json.RawMessage          `json:"git_data,omitempty"`
+	DeletedAt       *string                  `json:"deleted_at,omitempty"`
+	ParentTaskID    *string                  `json:"parent_task_id,omitempty"`
+	StartDate       *string                  `json:"start_date,omitempty"` // YYYY-MM-DD
+	DueDate         *string                  `json:"due_date,omitempty"`   // YYYY-MM-DD
+	EstimatePoints  *int32                   `json:"estimate_points,omitempty"`
+	Overdue         bool                     `json:"overdue"`                    // Past its due date and not done or cancelled
+	BlockedBy       []TaskRef                `json:"blocked_by"`                 // Tasks that must be closed before this one can start or finish
+	Blocks          []TaskRef                `json:"blocks"`                     // Tasks waiting on this one
+	SubtaskProgress *SubtaskProgressResponse `json:"subtask_progress,omitempty"` // Set when the task has subtasks
+	Subtasks        []TaskResponse           `json:"subtasks,omitempty"`         // The subtree, returned by GET /tasks/{id}
+	Labels          []LabelResponse          `json:"labels"`
+	Assignees       []AssigneeResponse       `json:"assignees"`
+}
+
+// SubtaskProgressResponse rolls up a task's subtasks at any depth. Cancelled
+// subtasks aren't counted.
+type SubtaskProgressResponse struct {
+	Total   int64 `json:"total"`
+	Done    int64 `json:"done"`
+	Percent int   `json:"percent"`
+}
+
+// AssigneeResponse identifies a user assigned to a task. The AI agent is
+// assigned as a user with the "agent" role.
+type AssigneeResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// TaskRef identifies a related task.
+type TaskRef struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Status *string `json:"status,omitempty"`
+}
+
+// UserResponse represents the HTTP response for a user.
+type UserResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Role      string  `json:"role"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	CreatedBy *string `json:"created_by,omitempty"`
+}
+
+// TaskListResponse represents one page of tasks.
+type TaskListResponse struct {
+	Items      []TaskResponse `json:"items"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
+// FeatureListResponse represents one page of features.
+type FeatureListResponse struct {
+	Items      []FeatureResponse `json:"items"`
+	NextCursor *string           `json:"next_cursor"`
+}
+
+// SearchResultResponse represents a single task or feature matched by a search.
+// Name and Snippet are safe HTML: the text is escaped and matches are wrapped
+// in <mark>.
+type SearchResultResponse struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id"`
+	FeatureID *string `json:"feature_id,omitempty"`
+	Name      string  `json:"name"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+}
+
+// WorkflowResponse represents the configured workflows for tasks and features.
+type WorkflowResponse struct {
+	Task    EntityWorkflowResponse `json:"task"`
+	Feature EntityWorkflowResponse `json:"feature"`
+	// DeriveFeatureStatus is set when features move to done with their last task.
+	DeriveFeatureStatus bool `json:"derive_feature_status"`
+}
+
+// EntityWorkflowResponse lists the statuses, allowed transitions and priorities of one entity type.
+type EntityWorkflowResponse struct {
+	InitialStatus string              `json:"initial_status"`
+	Statuses      []string            `json:"statuses"`
+	Transitions   map[string][]string `json:"transitions"`
+	Priorities    []string            `json:"priorities"`
+}
+
+// AuditEventResponse represents one entry in the change history of a task or feature.
+type AuditEventResponse struct {
+	ID         string          `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	ActorID    *string         `json:"actor_id,omitempty"`
+	Changes    json.RawMessage `json:"changes"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// TrashResponse represents the deleted tasks and features that can still be restored.
+type TrashResponse struct {
+	Tasks    []TaskResponse    `json:"tasks"`
+	Features []FeatureResponse `json:"features"`
+}
+
+// FeatureDeleteConflictResponse is returned when a feature can't be deleted
+// because tasks still belong to it.
+type FeatureDeleteConflictResponse struct {
+	Error   string   `json:"error"`
+	TaskIDs []string `json:"task_ids"`
+}
+
+// AddTaskBranchRequest represents the request body for linking a branch to a task.
+type AddTaskBranchRequest struct {
+	Repo string `json:"repo"` // owner/name
+	Name string `json:"name"`
+}
+
+// AddTaskPullRequestRequest represents the request body for linking a pull request to a task.
+type AddTaskPullRequestRequest struct {
+	Repo   string  `json:"repo"` // owner/name
+	Number int     `json:"number"`
+	URL    *string `json:"url"`
+	State  *string `json:"state"` // open (default), closed or merged
+	Branch *string `json:"branch"`
+}
+
+// AgentRunResponse represents a run of the coding agent on a task.
+type AgentRunResponse struct {
 	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
+	TaskID      string  `json:"task_id"`
+	Status      string  `json:"status"`
+	Attempts    int32   `json:"attempts"`
+	MaxAttempts int32   `json:"max_attempts"`
+	RunAfter    string  `json:"run_after"`
+	Provider    *string `json:"provider,omitempty"`
+	Model       *string `json:"model,omitempty"`
+	Prompt      *string `json:"prompt,omitempty"`
+	Output      *string `json:"output,omitempty"`
+	Error       *string `json:"error,omitempty"`
+	Logs        string  `json:"logs"`
+	CreatedBy   *string `json:"created_by,omitempty"`
 	CreatedAt   string  `json:"created_at"`
 	UpdatedAt   string  `json:"updated_at"`
-	CreatedBy   *string `json:"created_by,omitempty"`
-	Priority    *string `json:"priority,omitempty"`
-	Status      *string `json:"status,omitempty"`
+	StartedAt   *string `json:"started_at,omitempty"`
+	FinishedAt  *string `json:"finished_at,omitempty"`
 }
 
-// TaskResponse represents the HTTP response for a task.
-type TaskResponse struct {
-	ID          string          `json:"id"`
-	Name        string          `json:"name"`
-	Description *string         `json:"description,omitempty"`
-	CreatedAt   string          `json:"created_at"`
-	UpdatedAt   string          `json:"updated_at"`
-	CreatedBy   *string         `json:"created_by,omitempty"`
-	FeatureID   string          `json:"feature_id"`
-	FeatureName *string         `json:"feature_name,omitempty"`
-	Priority    *string         `json:"priority,omitempty"`
-	Status      *string         `json:"status,omitempty"`
-	GitData     json.RawMessage `json:"git_data,omitempty"`
+// ReviewCommentResponse represents a comment left on a pull request's diff.
+type ReviewCommentResponse struct {
+	ID        int64  `json:"id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	Path      string `json:"path"`
+	Line      int    `json:"line,omitempty"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+}
+
+// WebhookResponse lists the tasks a webhook event was applied to.
+type WebhookResponse struct {
+	Event string   `json:"event"`
+	Tasks []string `json:"tasks"`
+}
+
+// CreateAPITokenRequest represents the request body for creating a personal API token.
+type CreateAPITokenRequest struct {
+	Name      string  `json:"name"`
+	ExpiresAt *string `json:"expires_at"` // RFC 3339, never expires when omitted
+}
+
+// APITokenResponse represents a personal API token, without its secret.
+type APITokenResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	CreatedAt  string  `json:"created_at"`
+	ExpiresAt  *string `json:"expires_at,omitempty"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+}
+
+// CreateAPITokenResponse includes the token itself, which is only shown once.
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
+// SessionResponse represents a session token to send as a bearer token.
+type SessionResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// CreateCommentRequest represents the request body for commenting on a task.
+type CreateCommentRequest struct {
+	Body     string  `json:"body" example:"Looks good, but the **migration** needs an index."` // Markdown
+	ParentID *string `json:"parent_id,omitempty"`                                              // The comment this one replies to
+}
+
+// UpdateCommentRequest represents the request body for editing a comment.
+type UpdateCommentRequest struct {
+	Body string `json:"body"` // Markdown
+}
+
+// CommentResponse represents a comment on a task with its replies.
+type CommentResponse struct {
+	ID         string            `json:"id"`
+	TaskID     string            `json:"task_id"`
+	ParentID   *string           `json:"parent_id,omitempty"`
+	AuthorID   *string           `json:"author_id,omitempty"`
+	AuthorName string            `json:"author_name"`
+	AgentRunID *string           `json:"agent_run_id,omitempty"` // Set on agent run summaries
+	Body       string            `json:"body"`
+	CreatedAt  string            `json:"created_at"`
+	EditedAt   *string           `json:"edited_at,omitempty"`
+	Replies    []CommentResponse `json:"replies"`
+}
+
+// AddTaskDependencyRequest represents the request body for making a task
+// wait on another.
+type AddTaskDependencyRequest struct {
+	BlockedBy string `json:"blocked_by"` // ID of the blocking task
+}
+
+// DependencyGraphResponse represents the dependencies between a feature's
+// tasks. Edges point from the blocking task to the task it blocks.
+type DependencyGraphResponse struct {
+	FeatureID string                   `json:"feature_id"`
+	Nodes     []DependencyNodeResponse `json:"nodes"`
+	Edges     []DependencyEdgeResponse `json:"edges"`
+	DOT       string                   `json:"dot"` // The same graph in Graphviz DOT
+}
+
+type DependencyNodeResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Status    *string `json:"status,omitempty"`
+	FeatureID string  `json:"feature_id"`
+	External  bool    `json:"external"` // The task belongs to another feature
+}
+
+type DependencyEdgeResponse struct {
+	From string `json:"from"` // Blocking task
+	To   string `json:"to"`   // Blocked task
+}
+
+// CreateLabelRequest represents the request body for creating a label.
+type CreateLabelRequest struct {
+	Name  string `json:"name" example:"bug"`
+	Color string `json:"color" example:"#d73a4a"` // Hex color, #rrggbb
+}
+
+// UpdateLabelRequest represents the request body for renaming or recoloring
+// a label.
+type UpdateLabelRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"` // Hex color, #rrggbb
+}
+
+// AttachLabelRequest represents the request body for labelling a task or feature.
+type AttachLabelRequest struct {
+	LabelID string `json:"label_id"`
+}
+
+// LabelResponse represents a label.
+type LabelResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Color     string  `json:"color"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	CreatedBy *string `json:"created_by,omitempty"`
+}
+
+// AddTaskAssigneeRequest represents the request body for assigning a task.
+type AddTaskAssigneeRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// WorkLogResponse represents time logged on a task. A running timer has no
+// ended_at and its minutes are the time so far.
+type WorkLogResponse struct {
+	ID        string  `json:"id"`
+	TaskID    string  `json:"task_id"`
+	UserID    *string `json:"user_id,omitempty"`
+	UserName  string  `json:"user_name"`
+	StartedAt string  `json:"started_at"`
+	EndedAt   *string `json:"ended_at,omitempty"`
+	Minutes   int64   `json:"minutes"`
+	Running   bool    `json:"running"`
+	Note      *string `json:"note,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// TaskWorkLogsResponse represents the time logged on a task. TotalMinutes
+// leaves out running timers.
+type TaskWorkLogsResponse struct {
+	TotalMinutes int64             `json:"total_minutes"`
+	Items        []WorkLogResponse `json:"items"`
+}
+
+// CreateWorkLogRequest represents the request body for logging time on a task by hand.
+type CreateWorkLogRequest struct {
+	Minutes   int     `json:"minutes" example:"90"`
+	StartedAt *string `json:"started_at"` // RFC 3339, defaults to minutes ago
+	Note      *string `json:"note"`
+}
+
+// TimeReportResponse represents the time logged in a period.
+type TimeReportResponse struct {
+	From         string              `json:"from"`
+	To           string              `json:"to"`
+	TotalMinutes int64               `json:"total_minutes"`
+	ByTask       []TimeTotalResponse `json:"by_task"`
+	ByFeature    []TimeTotalResponse `json:"by_feature"`
+	ByUser       []TimeTotalResponse `json:"by_user"`
+}
+
+// TimeTotalResponse represents the time logged against a task or feature, or
+// by a user. Deleted users have no id.
+type TimeTotalResponse struct {
+	ID      string  `json:"id,omitempty"`
+	Name    string  `json:"name"`
+	Minutes int64   `json:"minutes"`
+	Hours   float64 `json:"hours"`
 }
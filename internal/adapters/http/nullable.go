@@ -0,0 +1,58 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+)
+
+// Nullable is an optional request field that tells an omitted value, which
+// keeps what is stored, from an explicit null, which clears it.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+// parseDate reads an optional YYYY-MM-DD date from a request field named key.
+func parseDate(key string, value *string) (pgt.Date, error) {
+	if value == nil {
+		return pgt.Date{Valid: false}, nil
+	}
+	t, err := time.Parse(time.DateOnly, *value)
+	if err != nil {
+		return pgt.Date{}, fmt.Errorf("invalid %s: expected YYYY-MM-DD date", key)
+	}
+	return pgt.Date{Time: t, Valid: true}, nil
+}
+
+func toInt4(value *int32) pgt.Int4 {
+	if value == nil {
+		return pgt.Int4{Valid: false}
+	}
+	return pgt.Int4{Int32: *value, Valid: true}
+}
+
+// formatDate formats an optional date column as YYYY-MM-DD.
+func formatDate(d pgt.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := d.Time.Format(time.DateOnly)
+	return &s
+}
@@ -0,0 +1,126 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type PullRequestHandler struct {
+	pullRequestService *services.PullRequestService
+	taskService        *services.TaskService
+}
+
+func NewPullRequestHandler(pullRequestService *services.PullRequestService, taskService *services.TaskService) *PullRequestHandler {
+	return &PullRequestHandler{pullRequestService: pullRequestService, taskService: taskService}
+}
+
+// RefreshTaskPullRequest
+// @Summary Refresh a linked pull request
+// @Description Fetch the state of a pull request linked to the task from the code host and store it in the task's git data
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param owner path string true "Repo owner"
+// @Param repo path string true "Repo name"
+// @Param number path int true "Pull request number"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or pull request number"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or pull request not found"
+// @Failure 502 {string} string "Code host failed"
+// @Failure 500 {string} string "Failed to refresh pull request"
+// @Router /tasks/{id}/pull-requests/{owner}/{repo}/{number}/refresh [post]
+func (h *PullRequestHandler) RefreshTaskPullRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, repo, number, ok := parsePullRequestPath(w, r, "RefreshTaskPullRequest")
+	if !ok {
+		return
+	}
+
+	task, err := h.pullRequestService.RefreshPullRequest(r.Context(), taskID, repo, number)
+	if errors.Is(err, services.ErrUpstream) {
+		fmt.Printf("RefreshTaskPullRequest: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeGitDataResult(w, r, h.taskService, "RefreshTaskPullRequest", "Failed to refresh pull request", task, err)
+}
+
+// ListPullRequestReviewComments
+// @Summary Get the review comments of a linked pull request
+// @Description Retrieve the comments reviewers left on the diff of a pull request linked to the task
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param owner path string true "Repo owner"
+// @Param repo path string true "Repo name"
+// @Param number path int true "Pull request number"
+// @Success 200 {array} ReviewCommentResponse
+// @Failure 400 {string} string "Invalid task ID or pull request number"
+// @Failure 404 {string} string "Task or pull request not found"
+// @Failure 502 {string} string "Code host failed"
+// @Failure 500 {string} string "Failed to list review comments"
+// @Router /tasks/{id}/pull-requests/{owner}/{repo}/{number}/review-comments [get]
+func (h *PullRequestHandler) ListPullRequestReviewComments(w http.ResponseWriter, r *http.Request) {
+	taskID, repo, number, ok := parsePullRequestPath(w, r, "ListPullRequestReviewComments")
+	if !ok {
+		return
+	}
+
+	comments, err := h.pullRequestService.ListReviewComments(r.Context(), taskID, repo, number)
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrUpstream) {
+		fmt.Printf("ListPullRequestReviewComments: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListPullRequestReviewComments: Failed to list review comments: %v\n", err)
+		http.Error(w, "Failed to list review comments", http.StatusInternalServerError)
+		return
+	}
+
+	commentResponses := make([]ReviewCommentResponse, len(comments))
+	for i, comment := range comments {
+		commentResponses[i] = ReviewCommentResponse{
+			ID:        comment.ID,
+			Author:    comment.Author,
+			Body:      comment.Body,
+			Path:      comment.Path,
+			Line:      comment.Line,
+			URL:       comment.URL,
+			CreatedAt: comment.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(commentResponses)
+}
+
+// parsePullRequestPath reads /tasks/{id}/pull-requests/{owner}/{repo}/{number}.
+// It writes a 400 and returns false when the path is invalid.
+func parsePullRequestPath(w http.ResponseWriter, r *http.Request, handler string) (pgt.UUID, string, int, bool) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("%s: Invalid task ID: %v\n", handler, err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return pgt.UUID{}, "", 0, false
+	}
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		fmt.Printf("%s: Invalid pull request number: %v\n", handler, err)
+		http.Error(w, "Invalid pull request number", http.StatusBadRequest)
+		return pgt.UUID{}, "", 0, false
+	}
+	return pgt.UUID{Bytes: taskID, Valid: true}, r.PathValue("owner") + "/" + r.PathValue("repo"), number, true
+}
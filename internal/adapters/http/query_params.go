@@ -0,0 +1,93 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/ports"
+)
+
+// parseUUIDQuery reads an optional UUID query parameter. A missing parameter
+// yields an invalid (NULL) UUID.
+func parseUUIDQuery(query url.Values, key string) (pgt.UUID, error) {
+	value := query.Get(key)
+	if value == "" {
+		return pgt.UUID{Valid: false}, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return pgt.UUID{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return pgt.UUID{Bytes: id, Valid: true}, nil
+}
+
+// parseTextQuery reads an optional text query parameter.
+func parseTextQuery(query url.Values, key string) pgt.Text {
+	value := query.Get(key)
+	if value == "" {
+		return pgt.Text{Valid: false}
+	}
+	return pgt.Text{String: value, Valid: true}
+}
+
+// parseBoolQuery reads an optional boolean query parameter, false when
+// missing.
+func parseBoolQuery(query url.Values, key string) (bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: expected true or false", key)
+	}
+	return b, nil
+}
+
+// parseTimeQuery reads an optional timestamp query parameter. Both RFC 3339
+// timestamps and plain dates (2006-01-02, midnight UTC) are accepted.
+func parseTimeQuery(query url.Values, key string) (pgt.Timestamptz, error) {
+	value := query.Get(key)
+	if value == "" {
+		return pgt.Timestamptz{Valid: false}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+	}
+	if err != nil {
+		return pgt.Timestamptz{}, fmt.Errorf("invalid %s: expected RFC 3339 timestamp or YYYY-MM-DD date", key)
+	}
+	return pgt.Timestamptz{Time: t, Valid: true}, nil
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD date query parameter.
+func parseDateQuery(query url.Values, key string) (pgt.Date, error) {
+	value := query.Get(key)
+	if value == "" {
+		return pgt.Date{Valid: false}, nil
+	}
+	return parseDate(key, &value)
+}
+
+// parsePageRequest reads the sort, order, cursor and limit query parameters
+// shared by all list endpoints.
+func parsePageRequest(query url.Values) (ports.PageRequest, error) {
+	page := ports.PageRequest{
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return ports.PageRequest{}, fmt.Errorf("invalid limit: must be a positive integer")
+		}
+		page.Limit = n
+	}
+	return page, nil
+}
@@ -0,0 +1,36 @@
+package httphandler
+
+import (
+	"net/url"
+	"testing"
+
+	"shelke.dev/api/internal/ports"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    ports.PageRequest
+		wantErr bool
+	}{
+		{"", ports.PageRequest{}, false},
+		{"sort=name&order=asc&cursor=abc&limit=20", ports.PageRequest{Sort: "name", Order: "asc", Cursor: "abc", Limit: 20}, false},
+		{"limit=0", ports.PageRequest{}, true},
+		{"limit=-1", ports.PageRequest{}, true},
+		{"limit=ten", ports.PageRequest{}, true},
+		{"limit=1.5", ports.PageRequest{}, true},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("url.ParseQuery(%q) = %v", tt.query, err)
+		}
+		got, err := parsePageRequest(query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePageRequest(%q) error = %v, want error %v", tt.query, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parsePageRequest(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"shelke.dev/api/internal/core/services"
+)
+
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Search
+// @Summary Search tasks and features
+// @Description Full-text search over task and feature names and descriptions, ranked by relevance. name and snippet are safe HTML: the text is escaped and matches are wrapped in <mark>.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Search terms (supports quoted phrases, or, and -exclusions)"
+// @Param limit query int false "Maximum number of results (max 100)"
+// @Success 200 {array} SearchResultResponse
+// @Failure 400 {string} string "Missing or invalid query"
+// @Failure 500 {string} string "Failed to search"
+// @Router /search [get]
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit: must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.searchService.Search(r.Context(), query.Get("q"), limit)
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Search: Failed to search: %v\n", err)
+		http.Error(w, "Failed to search", http.StatusInternalServerError)
+		return
+	}
+
+	resultResponses := make([]SearchResultResponse, len(results))
+	for i, result := range results {
+		resultResponses[i] = toSearchResultResponse(result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resultResponses)
+}
@@ -1,12 +1,16 @@
 package httphandler
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	httpSwagger "github.com/swaggo/http-swagger"
 	db "shelke.dev/api/db/sqlc"
 	_ "shelke.dev/api/docs"
+	"shelke.dev/api/internal/core/domain"
 	"shelke.dev/api/internal/core/services"
 	"shelke.dev/api/internal/ports"
 )
@@ -21,17 +25,41 @@ type Server struct {
 	healthCheckHandler *HealthCheckHandler
 	taskHandler        *TaskHandler
 	featureHandler     *FeatureHandler
+	userHandler        *UserHandler
+	searchHandler      *SearchHandler
+	workflowHandler    *WorkflowHandler
+	historyHandler     *HistoryHandler
+	trashHandler       *TrashHandler
+	agentHandler       *AgentHandler
+	pullRequestHandler *PullRequestHandler
+	webhookHandler     *WebhookHandler
+	authHandler        *AuthHandler
+	commentHandler     *CommentHandler
+	labelHandler       *LabelHandler
+	timeHandler        *TimeHandler
 }
 
-func NewServer(healthCheckService ports.HealthCheckService, queries *db.Queries) *Server {
-	taskService := services.NewTaskService(queries)
-	featureService := services.NewFeatureService(queries)
+func NewServer(healthCheckService ports.HealthCheckService, authService *services.AuthService, trashService *services.TrashService, taskService *services.TaskService, agentService *services.AgentService, pullRequestService *services.PullRequestService, githubWebhookSecret string, queries *db.Queries, pool *pgxpool.Pool, workflows domain.WorkflowConfig) *Server {
+	featureService := services.NewFeatureService(queries, pool, workflows.Feature)
+	userService := services.NewUserService(queries, pool)
 	server := &Server{
 		mux:                http.NewServeMux(),
 		middlewares:        []Middleware{},
 		healthCheckHandler: NewHealthCheckHandler(healthCheckService),
-		taskHandler:        NewTaskHandler(taskService, featureService), // Pass featureService
-		featureHandler:     NewFeatureHandler(featureService),
+		taskHandler:        NewTaskHandler(taskService, featureService, userService), // Pass featureService
+		featureHandler:     NewFeatureHandler(featureService, userService),
+		userHandler:        NewUserHandler(userService),
+		searchHandler:      NewSearchHandler(services.NewSearchService(queries)),
+		workflowHandler:    NewWorkflowHandler(services.NewWorkflowService(workflows)),
+		historyHandler:     NewHistoryHandler(services.NewAuditService(queries)),
+		trashHandler:       NewTrashHandler(trashService, featureService),
+		agentHandler:       NewAgentHandler(agentService),
+		pullRequestHandler: NewPullRequestHandler(pullRequestService, taskService),
+		webhookHandler:     NewWebhookHandler(pullRequestService, githubWebhookSecret),
+		authHandler:        NewAuthHandler(authService),
+		commentHandler:     NewCommentHandler(services.NewCommentService(queries)),
+		labelHandler:       NewLabelHandler(services.NewLabelService(queries, pool)),
+		timeHandler:        NewTimeHandler(services.NewTimeService(queries, pool)),
 	}
 	server.registerRoutes()
 	return server
@@ -46,14 +74,89 @@ func (s *Server) registerRoutes() {
 	// Task Routes
 	s.Add("POST /tasks", s.taskHandler.CreateTask)
 	s.Add("GET /tasks", s.taskHandler.ListTasks)
+	s.Add("GET /tasks/", s.taskHandler.GetTask)
 	s.Add("PUT /tasks/", s.taskHandler.UpdateTask)
 	s.Add("DELETE /tasks/", s.taskHandler.DeleteTask)
+	s.Add("GET /tasks/{id}/history", s.historyHandler.TaskHistory)
+	s.Add("POST /tasks/{id}/restore", s.trashHandler.RestoreTask)
+	s.Add("POST /tasks/{id}/branches", s.taskHandler.AddTaskBranch)
+	s.Add("DELETE /tasks/{id}/branches/{owner}/{repo}/{branch...}", s.taskHandler.RemoveTaskBranch)
+	s.Add("POST /tasks/{id}/pull-requests", s.taskHandler.AddTaskPullRequest)
+	s.Add("DELETE /tasks/{id}/pull-requests/{owner}/{repo}/{number}", s.taskHandler.RemoveTaskPullRequest)
+	s.Add("POST /tasks/{id}/pull-requests/{owner}/{repo}/{number}/refresh", s.pullRequestHandler.RefreshTaskPullRequest)
+	s.Add("GET /tasks/{id}/pull-requests/{owner}/{repo}/{number}/review-comments", s.pullRequestHandler.ListPullRequestReviewComments)
+	s.Add("POST /tasks/{id}/agent-runs", s.agentHandler.StartAgentRun)
+	s.Add("GET /tasks/{id}/agent-runs", s.agentHandler.ListAgentRuns)
+	s.Add("POST /tasks/{id}/dependencies", s.taskHandler.AddTaskDependency)
+	s.Add("DELETE /tasks/{id}/dependencies/{blockerId}", s.taskHandler.RemoveTaskDependency)
+	s.Add("GET /tasks/{id}/comments", s.commentHandler.ListTaskComments)
+	s.Add("POST /tasks/{id}/comments", s.commentHandler.CreateTaskComment)
+	s.Add("PUT /tasks/{id}/comments/{commentId}", s.commentHandler.UpdateTaskComment)
+	s.Add("DELETE /tasks/{id}/comments/{commentId}", s.commentHandler.DeleteTaskComment)
+	s.Add("POST /tasks/{id}/labels", s.labelHandler.AddTaskLabel)
+	s.Add("DELETE /tasks/{id}/labels/{labelId}", s.labelHandler.RemoveTaskLabel)
+	s.Add("POST /tasks/{id}/assignees", s.taskHandler.AddTaskAssignee)
+	s.Add("DELETE /tasks/{id}/assignees/{userId}", s.taskHandler.RemoveTaskAssignee)
+	s.Add("POST /tasks/{id}/timer/start", s.timeHandler.StartTimer)
+	s.Add("POST /tasks/{id}/timer/stop", s.timeHandler.StopTimer)
+	s.Add("GET /tasks/{id}/work-logs", s.timeHandler.ListWorkLogs)
+	s.Add("POST /tasks/{id}/work-logs", s.timeHandler.CreateWorkLog)
+	s.Add("DELETE /tasks/{id}/work-logs/{logId}", s.timeHandler.DeleteWorkLog)
 
 	// Feature Routes
 	s.Add("POST /features", s.featureHandler.CreateFeature)
 	s.Add("GET /features", s.featureHandler.ListFeatures)
+	s.Add("GET /features/", s.featureHandler.GetFeature)
 	s.Add("PUT /features/", s.featureHandler.UpdateFeature)
 	s.Add("DELETE /features/", s.featureHandler.DeleteFeature)
+	s.Add("GET /features/{id}/owners", s.featureHandler.ListFeatureOwners)
+	s.Add("POST /features/{id}/owners", s.featureHandler.AddFeatureOwner)
+	s.Add("DELETE /features/{id}/owners/{userId}", s.featureHandler.RemoveFeatureOwner)
+	s.Add("GET /features/{id}/dependency-graph", s.taskHandler.GetDependencyGraph)
+	s.Add("GET /features/{id}/history", s.historyHandler.FeatureHistory)
+	s.Add("POST /features/{id}/restore", s.trashHandler.RestoreFeature)
+	s.Add("POST /features/{id}/labels", s.labelHandler.AddFeatureLabel)
+	s.Add("DELETE /features/{id}/labels/{labelId}", s.labelHandler.RemoveFeatureLabel)
+
+	// Label Routes
+	s.Add("GET /labels", s.labelHandler.ListLabels)
+	s.Add("POST /labels", s.labelHandler.CreateLabel)
+	s.Add("PUT /labels/{id}", s.labelHandler.UpdateLabel)
+	s.Add("DELETE /labels/{id}", s.labelHandler.DeleteLabel)
+
+	// Report Routes
+	s.Add("GET /reports/time", s.timeHandler.GetTimeReport)
+
+	// Agent Run Routes
+	s.Add("GET /agent-runs/{id}", s.agentHandler.GetAgentRun)
+	s.Add("POST /agent-runs/{id}/cancel", s.agentHandler.CancelAgentRun)
+
+	// Trash Routes
+	s.Add("GET /trash", s.trashHandler.ListTrash)
+
+	// Auth Routes
+	s.Add("GET /auth/me", s.authHandler.GetCurrentUser)
+	s.Add("POST /auth/sessions", s.authHandler.CreateSession)
+	s.Add("POST /auth/tokens", s.authHandler.CreateAPIToken)
+	s.Add("GET /auth/tokens", s.authHandler.ListAPITokens)
+	s.Add("DELETE /auth/tokens/{id}", s.authHandler.RevokeAPIToken)
+
+	// Webhook Routes
+	s.Add("POST /webhooks/github", s.webhookHandler.GitHubWebhook)
+
+	// User Routes
+	s.Add("POST /users", s.userHandler.CreateUser)
+	s.Add("GET /users", s.userHandler.ListUsers)
+	s.Add("GET /users/", s.userHandler.GetUser)
+	s.Add("PUT /users/", s.userHandler.UpdateUser)
+	s.Add("DELETE /users/", s.userHandler.DeleteUser)
+	s.Add("GET /users/{id}/tasks", s.taskHandler.ListUserTasks)
+
+	// Search Routes
+	s.Add("GET /search", s.searchHandler.Search)
+
+	// Workflow Routes
+	s.Add("GET /workflow", s.workflowHandler.GetWorkflow)
 
 		s.Add("GET /swagger/", httpSwagger.WrapHandler.ServeHTTP)
 
@@ -77,13 +180,54 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// publicPaths are served without authentication: health checks, the API
+// docs, and webhooks, which verify their own signatures.
+var publicPaths = []string{"/health", "/swagger/", "/webhooks/"}
+
+// AuthMiddleware authenticates the request with the bearer token in the
+// Authorization header, either a personal API token or a session token, and
+// puts the user into the request context. Requests without a valid token get
+// a 401, except on publicPaths.
+func AuthMiddleware(authService *services.AuthService) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, path := range publicPaths {
+				if strings.HasPrefix(r.URL.Path, path) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+				return
+			}
+			user, err := authService.Authenticate(r.Context(), token)
+			if errors.Is(err, services.ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				log.Printf("AuthMiddleware: Failed to authenticate request: %v", err)
+				http.Error(w, "Failed to authenticate request", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(services.ContextWithUser(r.Context(), user)))
+		})
+	}
+}
+
 func (s *Server) Use(middleware Middleware) {
 	s.middlewares = append(s.middlewares, middleware)
 }
 
+// Add registers a route. Middlewares are applied when the request is served,
+// so ones added through Use after the routes were registered still run.
 func (s *Server) Add(path string, handler HandlerFunc) {
-	finalHandler := applyMiddlewares(http.HandlerFunc(handler), s.middlewares...)
 	s.mux.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		finalHandler.ServeHTTP(w, r)
+		applyMiddlewares(http.HandlerFunc(handler), s.middlewares...).ServeHTTP(w, r)
 	}))
 }
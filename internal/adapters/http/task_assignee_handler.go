@@ -0,0 +1,168 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+// AddTaskAssignee
+// @Summary Assign a task
+// @Description Assign a user to the task. Tasks can have several assignees. Assigning the agent user (00000000-0000-0000-0000-000000000001) queues an agent run on the task.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param assignee body AddTaskAssigneeRequest true "Assignee request"
+// @Success 201 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or user not found"
+// @Failure 500 {string} string "Failed to assign task"
+// @Router /tasks/{id}/assignees [post]
+func (h *TaskHandler) AddTaskAssignee(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("AddTaskAssignee: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody AddTaskAssigneeRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("AddTaskAssignee: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := uuid.Parse(reqBody.UserID)
+	if err != nil {
+		fmt.Printf("AddTaskAssignee: Invalid UserID: %v\n", err)
+		http.Error(w, "Invalid UserID format", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskService.AssignTask(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pgt.UUID{Bytes: userID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task or user not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("AddTaskAssignee: Failed to assign task: %v\n", err)
+		http.Error(w, "Failed to assign task", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("AddTaskAssignee: Task %s assigned to %s\n", taskID.String(), userID.String())
+
+	h.writeTaskWithDetails(w, r, "AddTaskAssignee", "Failed to assign task", http.StatusCreated, task)
+}
+
+// RemoveTaskAssignee
+// @Summary Unassign a task
+// @Description Remove a user from the task's assignees
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task or user ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found or user not assigned"
+// @Failure 500 {string} string "Failed to unassign task"
+// @Router /tasks/{id}/assignees/{userId} [delete]
+func (h *TaskHandler) RemoveTaskAssignee(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RemoveTaskAssignee: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	userID, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		fmt.Printf("RemoveTaskAssignee: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskService.UnassignTask(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pgt.UUID{Bytes: userID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RemoveTaskAssignee: Failed to unassign task: %v\n", err)
+		http.Error(w, "Failed to unassign task", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RemoveTaskAssignee: Task %s unassigned from %s\n", taskID.String(), userID.String())
+
+	h.writeTaskWithDetails(w, r, "RemoveTaskAssignee", "Failed to unassign task", http.StatusOK, task)
+}
+
+// ListUserTasks
+// @Summary List a user's tasks
+// @Description Retrieve the tasks assigned to the user that aren't in the trash, most recently updated first
+// @Tags Users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param status query string false "Only tasks with this status"
+// @Success 200 {array} TaskResponse
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Failed to list tasks"
+// @Router /users/{id}/tasks [get]
+func (h *TaskHandler) ListUserTasks(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("ListUserTasks: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.taskService.ListAssignedTasks(r.Context(), pgt.UUID{Bytes: userID, Valid: true}, parseTextQuery(r.URL.Query(), "status"))
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListUserTasks: Failed to list tasks: %v\n", err)
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+
+	taskIDs := make([]pgt.UUID, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+	details, err := h.taskService.ListTaskDetails(r.Context(), taskIDs)
+	if err != nil {
+		fmt.Printf("ListUserTasks: Failed to load task details: %v\n", err)
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]TaskResponse, len(tasks))
+	for i, task := range tasks {
+		response[i] = toTaskResponse(task, details[task.ID])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
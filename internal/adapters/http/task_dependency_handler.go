@@ -0,0 +1,184 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/services"
+)
+
+// AddTaskDependency
+// @Summary Make a task wait on another
+// @Description Record that the task is blocked by another task. The task can't move to in_progress or done while a blocker is open. Dependencies that would create a cycle are rejected.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param dependency body AddTaskDependencyRequest true "Dependency request"
+// @Success 201 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 422 {string} string "The dependency would create a cycle"
+// @Failure 500 {string} string "Failed to add dependency"
+// @Router /tasks/{id}/dependencies [post]
+func (h *TaskHandler) AddTaskDependency(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("AddTaskDependency: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody AddTaskDependencyRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("AddTaskDependency: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	blockerID, err := uuid.Parse(reqBody.BlockedBy)
+	if err != nil {
+		fmt.Printf("AddTaskDependency: Invalid BlockedBy: %v\n", err)
+		http.Error(w, "Invalid BlockedBy format", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskService.AddTaskDependency(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pgt.UUID{Bytes: blockerID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("AddTaskDependency: Failed to add dependency: %v\n", err)
+		http.Error(w, "Failed to add dependency", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("AddTaskDependency: Task %s is blocked by %s\n", taskID.String(), blockerID.String())
+
+	h.writeTaskWithDetails(w, r, "AddTaskDependency", "Failed to add dependency", http.StatusCreated, task)
+}
+
+// RemoveTaskDependency
+// @Summary Stop a task waiting on another
+// @Description Remove the dependency of the task on the given blocking task
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param blockerId path string true "Blocking task ID"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or dependency not found"
+// @Failure 500 {string} string "Failed to remove dependency"
+// @Router /tasks/{id}/dependencies/{blockerId} [delete]
+func (h *TaskHandler) RemoveTaskDependency(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RemoveTaskDependency: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	blockerID, err := uuid.Parse(r.PathValue("blockerId"))
+	if err != nil {
+		fmt.Printf("RemoveTaskDependency: Invalid blocking task ID: %v\n", err)
+		http.Error(w, "Invalid blocking task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskService.RemoveTaskDependency(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pgt.UUID{Bytes: blockerID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RemoveTaskDependency: Failed to remove dependency: %v\n", err)
+		http.Error(w, "Failed to remove dependency", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RemoveTaskDependency: Task %s is no longer blocked by %s\n", taskID.String(), blockerID.String())
+
+	h.writeTaskWithDetails(w, r, "RemoveTaskDependency", "Failed to remove dependency", http.StatusOK, task)
+}
+
+// GetDependencyGraph
+// @Summary Get the dependency graph of a feature
+// @Description Retrieve the DAG of dependencies between the feature's tasks, with tasks of other features they depend on marked external. The response includes the graph in Graphviz DOT; use format=dot to get the DOT source alone.
+// @Tags Features
+// @Produce json
+// @Produce text/vnd.graphviz
+// @Param id path string true "Feature ID"
+// @Param format query string false "Response format: json (default) or dot"
+// @Success 200 {object} DependencyGraphResponse
+// @Failure 400 {string} string "Invalid feature ID or format"
+// @Failure 404 {string} string "Feature not found"
+// @Failure 500 {string} string "Failed to get dependency graph"
+// @Router /features/{id}/dependency-graph [get]
+func (h *TaskHandler) GetDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	featureID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("GetDependencyGraph: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		http.Error(w, "format must be json or dot", http.StatusBadRequest)
+		return
+	}
+
+	graph, err := h.taskService.DependencyGraph(r.Context(), pgt.UUID{Bytes: featureID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetDependencyGraph: Failed to get dependency graph: %v\n", err)
+		http.Error(w, "Failed to get dependency graph", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		fmt.Fprint(w, graph.DOT())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toDependencyGraphResponse(featureID.String(), graph))
+}
+
+// writeTaskWithDetails writes the task after one of its relations changed,
+// with the details it has now.
+func (h *TaskHandler) writeTaskWithDetails(w http.ResponseWriter, r *http.Request, handler, failure string, status int, task db.Task) {
+	details, err := h.taskService.GetTaskDetails(r.Context(), task.ID)
+	if err != nil {
+		fmt.Printf("%s: Failed to load task details: %v\n", handler, err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(toTaskResponse(task, details))
+}
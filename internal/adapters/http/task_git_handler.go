@@ -0,0 +1,197 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/core/services"
+)
+
+// AddTaskBranch
+// @Summary Link a branch to a task
+// @Description Add a branch to the task's git data. The branch's repo is linked too if it isn't already.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param branch body AddTaskBranchRequest true "Branch to link"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 422 {string} string "Invalid branch"
+// @Failure 500 {string} string "Failed to link branch"
+// @Router /tasks/{id}/branches [post]
+func (h *TaskHandler) AddTaskBranch(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("AddTaskBranch: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody AddTaskBranchRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		fmt.Printf("AddTaskBranch: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskService.AddTaskBranch(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, domain.GitBranch{
+		Repo: reqBody.Repo,
+		Name: reqBody.Name,
+	})
+	writeGitDataResult(w, r, h.taskService, "AddTaskBranch", "Failed to link branch", task, err)
+}
+
+// RemoveTaskBranch
+// @Summary Unlink a branch from a task
+// @Description Remove a branch from the task's git data. The repo stays linked.
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param owner path string true "Repo owner"
+// @Param repo path string true "Repo name"
+// @Param branch path string true "Branch name, may contain slashes"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or branch not found"
+// @Failure 500 {string} string "Failed to unlink branch"
+// @Router /tasks/{id}/branches/{owner}/{repo}/{branch} [delete]
+func (h *TaskHandler) RemoveTaskBranch(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RemoveTaskBranch: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	task, err := h.taskService.RemoveTaskBranch(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, repo, r.PathValue("branch"))
+	writeGitDataResult(w, r, h.taskService, "RemoveTaskBranch", "Failed to unlink branch", task, err)
+}
+
+// AddTaskPullRequest
+// @Summary Link a pull request to a task
+// @Description Add a pull request to the task's git data, or update it if it is already linked. The pull request's repo is linked too if it isn't already. Linking an open pull request moves the task to review and a merged one moves it to done, when its workflow allows it.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param pull_request body AddTaskPullRequestRequest true "Pull request to link"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 422 {string} string "Invalid pull request"
+// @Failure 500 {string} string "Failed to link pull request"
+// @Router /tasks/{id}/pull-requests [post]
+func (h *TaskHandler) AddTaskPullRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("AddTaskPullRequest: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody AddTaskPullRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		fmt.Printf("AddTaskPullRequest: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pr := domain.GitPullRequest{
+		Repo:   reqBody.Repo,
+		Number: reqBody.Number,
+		State:  domain.PullRequestOpen,
+	}
+	if reqBody.URL != nil {
+		pr.URL = *reqBody.URL
+	}
+	if reqBody.State != nil {
+		pr.State = *reqBody.State
+	}
+	if reqBody.Branch != nil {
+		pr.Branch = *reqBody.Branch
+	}
+
+	task, err := h.taskService.AddTaskPullRequest(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pr)
+	writeGitDataResult(w, r, h.taskService, "AddTaskPullRequest", "Failed to link pull request", task, err)
+}
+
+// RemoveTaskPullRequest
+// @Summary Unlink a pull request from a task
+// @Description Remove a pull request from the task's git data. The repo stays linked.
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param owner path string true "Repo owner"
+// @Param repo path string true "Repo name"
+// @Param number path int true "Pull request number"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID or pull request number"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task or pull request not found"
+// @Failure 500 {string} string "Failed to unlink pull request"
+// @Router /tasks/{id}/pull-requests/{owner}/{repo}/{number} [delete]
+func (h *TaskHandler) RemoveTaskPullRequest(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RemoveTaskPullRequest: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		fmt.Printf("RemoveTaskPullRequest: Invalid pull request number: %v\n", err)
+		http.Error(w, "Invalid pull request number", http.StatusBadRequest)
+		return
+	}
+
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	task, err := h.taskService.RemoveTaskPullRequest(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, repo, number)
+	writeGitDataResult(w, r, h.taskService, "RemoveTaskPullRequest", "Failed to unlink pull request", task, err)
+}
+
+// writeGitDataResult writes the task after a git data change, or maps the
+// service error to a status code.
+func writeGitDataResult(w http.ResponseWriter, r *http.Request, taskService *services.TaskService, handler, failure string, task db.Task, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("%s: %s: %v\n", handler, failure, err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("%s: Task git data updated successfully: %s\n", handler, uuid.UUID(task.ID.Bytes).String())
+
+	details, err := taskService.GetTaskDetails(r.Context(), task.ID)
+	if err != nil {
+		fmt.Printf("%s: Failed to load task details: %v\n", handler, err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task, details))
+}
@@ -2,6 +2,7 @@ package httphandler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	pgt "github.com/jackc/pgx/v5/pgtype" // Import pgtype with an alias
 	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
 	"shelke.dev/api/internal/core/services"
 	// "github.com/sqlc-dev/pqtype" // No longer needed, pgtype.JSONB is used
 	// "database/sql" // No longer needed, pgtype.Text is used
@@ -17,10 +19,11 @@ import (
 type TaskHandler struct {
 	taskService    *services.TaskService
 	featureService *services.FeatureService
+	userService    *services.UserService
 }
 
-func NewTaskHandler(taskService *services.TaskService, featureService *services.FeatureService) *TaskHandler {
-	return &TaskHandler{taskService: taskService, featureService: featureService}
+func NewTaskHandler(taskService *services.TaskService, featureService *services.FeatureService, userService *services.UserService) *TaskHandler {
+	return &TaskHandler{taskService: taskService, featureService: featureService, userService: userService}
 }
 
 // CreateTask
@@ -32,6 +35,8 @@ func NewTaskHandler(taskService *services.TaskService, featureService *services.
 // @Param task body CreateTaskRequest true "Task creation request"
 // @Success 201 {object} TaskResponse
 // @Failure 400 {string} string "Invalid request body or format"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 422 {string} string "Unknown status or priority, or invalid parent task"
 // @Failure 500 {string} string "Failed to create task"
 // @Router /tasks [post]
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -48,17 +53,23 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	if reqBody.FeatureID != nil {
 		featureIDLog = *reqBody.FeatureID
 	}
-	createdByLog := "<nil>"
-	if reqBody.CreatedBy != nil {
-		createdByLog = *reqBody.CreatedBy
-	}
-	fmt.Printf("CreateTask: Decoded request body: Name:%s Description:%v CreatedBy:%s FeatureID:%s Priority:%v Status:%v GitData:%v\n",
-		reqBody.Name, reqBody.Description, createdByLog, featureIDLog, reqBody.Priority, reqBody.Status, reqBody.GitData)
+	fmt.Printf("CreateTask: Decoded request body: Name:%s Description:%v FeatureID:%s Priority:%v Status:%v GitData:%v\n",
+		reqBody.Name, reqBody.Description, featureIDLog, reqBody.Priority, reqBody.Status, reqBody.GitData)
 
 	arg := db.CreateTaskParams{
 		Name: reqBody.Name,
 	}
 
+	if reqBody.ParentTaskID != nil {
+		parentUUID, err := uuid.Parse(*reqBody.ParentTaskID)
+		if err != nil {
+			fmt.Printf("CreateTask: Invalid ParentTaskID: %v\n", err)
+			http.Error(w, "Invalid ParentTaskID format", http.StatusBadRequest)
+			return
+		}
+		arg.ParentTaskID = pgt.UUID{Bytes: parentUUID, Valid: true}
+	}
+
 	var featureUUID uuid.UUID
 	if reqBody.FeatureID != nil {
 		featureUUID, err = uuid.Parse(*reqBody.FeatureID)
@@ -68,6 +79,20 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		arg.FeatureID = pgt.UUID{Bytes: featureUUID, Valid: true}
+	} else if arg.ParentTaskID.Valid {
+		// Subtasks default to their parent's feature.
+		parent, err := h.taskService.GetTask(r.Context(), arg.ParentTaskID)
+		if errors.Is(err, services.ErrNotFound) {
+			http.Error(w, "Parent task not found for provided ParentTaskID", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			fmt.Printf("CreateTask: Failed to fetch parent task for ID %s: %v\n", *reqBody.ParentTaskID, err)
+			http.Error(w, "Failed to fetch parent task for provided ParentTaskID", http.StatusInternalServerError)
+			return
+		}
+		featureUUID = parent.FeatureID.Bytes
+		arg.FeatureID = parent.FeatureID
 	} else {
 		fmt.Printf("CreateTask: FeatureID is required\n")
 		http.Error(w, "FeatureID is required", http.StatusBadRequest)
@@ -76,6 +101,10 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch feature name using featureService
 	feature, err := h.featureService.GetFeature(r.Context(), pgt.UUID{Bytes: featureUUID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found for provided FeatureID", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		fmt.Printf("CreateTask: Failed to fetch feature for ID %s: %v\n", featureUUID.String(), err)
 		http.Error(w, "Failed to fetch feature for provided FeatureID", http.StatusInternalServerError)
@@ -86,15 +115,6 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	if reqBody.Description != nil {
 		arg.Description = pgt.Text{String: *reqBody.Description, Valid: true}
 	}
-	if reqBody.CreatedBy != nil {
-		createdByUUID, err := uuid.Parse(*reqBody.CreatedBy)
-		if err != nil {
-			fmt.Printf("CreateTask: Invalid CreatedBy UUID: %v\n", err)
-			http.Error(w, "Invalid CreatedBy UUID format", http.StatusBadRequest)
-			return
-		}
-		arg.CreatedBy = pgt.UUID{Bytes: createdByUUID, Valid: true}
-	}
 	if reqBody.Priority != nil {
 		arg.Priority = pgt.Text{String: *reqBody.Priority, Valid: true}
 	}
@@ -104,10 +124,27 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	if reqBody.GitData != nil { // Check if json.RawMessage is not nil
 		arg.GitData = reqBody.GitData // Assign directly as []byte
 	}
+	if arg.StartDate, err = parseDate("start_date", reqBody.StartDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.DueDate, err = parseDate("due_date", reqBody.DueDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	arg.EstimatePoints = toInt4(reqBody.EstimatePoints)
 
 	fmt.Printf("CreateTask: Calling service with arguments: %+v\n", arg)
 
 	task, err := h.taskService.CreateTask(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		fmt.Printf("CreateTask: Failed to create task: %v\n", err)
 		http.Error(w, "Failed to create task", http.StatusInternalServerError)
@@ -118,21 +155,107 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(toTaskResponse(task))
+	json.NewEncoder(w).Encode(toTaskResponse(task, services.TaskDetails{}))
 }
 
 // ListTasks
-// @Summary Get all tasks
-// @Description Retrieve a list of all tasks
+// @Summary Get tasks
+// @Description Retrieve a filtered, sorted page of tasks. The tasks are in items; pass next_cursor as cursor to get the next page, it is null on the last one. Before pagination this returned a bare array of tasks.
 // @Tags Tasks
 // @Produce json
-// @Success 200 {array} TaskResponse
+// @Param feature_id query string false "Only tasks of this feature"
+// @Param status query string false "Only tasks with this status"
+// @Param priority query string false "Only tasks with this priority"
+// @Param created_by query string false "Only tasks created by this user"
+// @Param parent_task_id query string false "Only the direct subtasks of this task"
+// @Param top_level query bool false "Only tasks that aren't subtasks"
+// @Param created_after query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_after query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_before query string false "Updated before (RFC 3339 or YYYY-MM-DD)"
+// @Param due_before query string false "Due before this date (YYYY-MM-DD)"
+// @Param overdue query bool false "Only tasks past their due date that aren't done or cancelled"
+// @Param repo query string false "Only tasks linked to this repo, as owner/name"
+// @Param label query string false "Only tasks with this label, by name (case-insensitive)"
+// @Param sort query string false "Sort column: created_at, updated_at, name, priority or status. priority and status sort alphabetically, not in workflow order"
+// @Param order query string false "asc or desc"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param limit query int false "Page size (max 200)"
+// @Success 200 {object} TaskListResponse
+// @Failure 400 {string} string "Invalid query parameters"
 // @Failure 500 {string} string "Failed to list tasks"
 // @Router /tasks [get]
 func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	arg := db.ListTasksParams{
+		Status:   parseTextQuery(query, "status"),
+		Priority: parseTextQuery(query, "priority"),
+		Label:    parseTextQuery(query, "label"),
+	}
+	var err error
+	if arg.FeatureID, err = parseUUIDQuery(query, "feature_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.CreatedBy, err = parseUUIDQuery(query, "created_by"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.ParentTaskID, err = parseUUIDQuery(query, "parent_task_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.TopLevel, err = parseBoolQuery(query, "top_level"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.CreatedAfter, err = parseTimeQuery(query, "created_after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.CreatedBefore, err = parseTimeQuery(query, "created_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.UpdatedAfter, err = parseTimeQuery(query, "updated_after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.UpdatedBefore, err = parseTimeQuery(query, "updated_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.DueBefore, err = parseDateQuery(query, "due_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if arg.Overdue, err = parseBoolQuery(query, "overdue"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if repo := query.Get("repo"); repo != "" {
+		owner, name, err := domain.ParseRepoFullName(repo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		arg.RepoOwner = pgt.Text{String: owner, Valid: true}
+		arg.RepoName = pgt.Text{String: name, Valid: true}
+	}
+	page, err := parsePageRequest(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	fmt.Println("ListTasks: Calling service to list tasks")
 
-	tasks, err := h.taskService.ListTasks(r.Context())
+	tasks, nextCursor, err := h.taskService.ListTasks(r.Context(), arg, page)
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		fmt.Printf("ListTasks: Failed to list tasks: %v\n", err)
 		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
@@ -141,13 +264,77 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("ListTasks: Successfully listed %d tasks\n", len(tasks))
 
-	taskResponses := make([]TaskResponse, len(tasks))
+	taskIDs := make([]pgt.UUID, len(tasks))
 	for i, task := range tasks {
-		taskResponses[i] = toTaskResponse(task)
+		taskIDs[i] = task.ID
+	}
+	details, err := h.taskService.ListTaskDetails(r.Context(), taskIDs)
+	if err != nil {
+		fmt.Printf("ListTasks: Failed to load task details: %v\n", err)
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+
+	response := TaskListResponse{Items: make([]TaskResponse, len(tasks))}
+	for i, task := range tasks {
+		response.Items[i] = toTaskResponse(task, details[task.ID])
+	}
+	if nextCursor != "" {
+		response.NextCursor = &nextCursor
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTask
+// @Summary Get a task
+// @Description Retrieve a single task by its ID, with its subtasks nested at any depth
+// @Tags Tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Failed to get task"
+// @Router /tasks/{id} [get]
+func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/tasks/")
+	if idStr == "" {
+		http.Error(w, "Task ID is required", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		fmt.Printf("GetTask: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, subtasks, err := h.taskService.GetTaskTree(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetTask: Failed to get task: %v\n", err)
+		http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		return
+	}
+
+	taskIDs := []pgt.UUID{task.ID}
+	for _, subtask := range subtasks {
+		taskIDs = append(taskIDs, subtask.ID)
+	}
+	details, err := h.taskService.ListTaskDetails(r.Context(), taskIDs)
+	if err != nil {
+		fmt.Printf("GetTask: Failed to load task details: %v\n", err)
+		http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(taskResponses)
+	json.NewEncoder(w).Encode(toTaskTreeResponse(task, subtasks, details))
 }
 
 // UpdateTask
@@ -160,6 +347,10 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 // @Param task body UpdateTaskRequest true "Task update request"
 // @Success 200 {object} TaskResponse
 // @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 409 {string} string "Status transition not allowed, or the task has open blockers"
+// @Failure 422 {string} string "Unknown status or priority"
 // @Failure 500 {string} string "Failed to update task"
 // @Router /tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -212,6 +403,10 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 		// Fetch feature name using featureService
 		feature, err := h.featureService.GetFeature(r.Context(), pgt.UUID{Bytes: featureUUID, Valid: true})
+		if errors.Is(err, services.ErrNotFound) {
+			http.Error(w, "Feature not found for provided FeatureID", http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			fmt.Printf("UpdateTask: Failed to fetch feature for ID %s: %v\n", featureUUID.String(), err)
 			http.Error(w, "Failed to fetch feature for provided FeatureID", http.StatusInternalServerError)
@@ -238,10 +433,44 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	} else {
 		arg.GitData = nil // Explicitly set to nil if not provided
 	}
+	if reqBody.StartDate.Set {
+		arg.SetStartDate = true
+		if arg.StartDate, err = parseDate("start_date", reqBody.StartDate.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if reqBody.DueDate.Set {
+		arg.SetDueDate = true
+		if arg.DueDate, err = parseDate("due_date", reqBody.DueDate.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if reqBody.EstimatePoints.Set {
+		arg.SetEstimatePoints = true
+		arg.EstimatePoints = toInt4(reqBody.EstimatePoints.Value)
+	}
 
 	fmt.Printf("UpdateTask: Calling service with arguments: %+v\n", arg)
 
 	task, err := h.taskService.UpdateTask(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		fmt.Printf("UpdateTask: Failed to update task: %v\n", err)
 		http.Error(w, "Failed to update task", http.StatusInternalServerError)
@@ -250,18 +479,27 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("UpdateTask: Task updated successfully: %+v\n", task)
 
+	details, err := h.taskService.GetTaskDetails(r.Context(), task.ID)
+	if err != nil {
+		fmt.Printf("UpdateTask: Failed to load task details: %v\n", err)
+		http.Error(w, "Failed to update task", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(toTaskResponse(task))
+	json.NewEncoder(w).Encode(toTaskResponse(task, details))
 }
 
 // DeleteTask
 // @Summary Delete a task
-// @Description Delete a task by its ID
+// @Description Move a task and its subtasks to the trash. They can be restored until they are purged.
 // @Tags Tasks
 // @Produce json
 // @Param id path string true "Task ID"
 // @Success 204 "No Content"
 // @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
 // @Failure 500 {string} string "Failed to delete task"
 // @Router /tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
@@ -278,6 +516,14 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.taskService.DeleteTask(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		fmt.Printf("DeleteTask: Failed to delete task: %v\n", err)
 		http.Error(w, "Failed to delete task", http.StatusInternalServerError)
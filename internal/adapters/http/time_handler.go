@@ -0,0 +1,345 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type TimeHandler struct {
+	timeService *services.TimeService
+}
+
+func NewTimeHandler(timeService *services.TimeService) *TimeHandler {
+	return &TimeHandler{timeService: timeService}
+}
+
+// StartTimer
+// @Summary Start a timer on a task
+// @Description Start tracking the caller's time on the task. Each user can have one timer running.
+// @Tags Time
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 201 {object} WorkLogResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 409 {string} string "A timer is already running"
+// @Failure 500 {string} string "Failed to start timer"
+// @Router /tasks/{id}/timer/start [post]
+func (h *TimeHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("StartTimer: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	log, err := h.timeService.StartTimer(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("StartTimer: Failed to start timer: %v\n", err)
+		http.Error(w, "Failed to start timer", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("StartTimer: Timer started on task %s: %s\n", taskID.String(), uuid.UUID(log.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toWorkLogResponse(log, time.Now()))
+}
+
+// StopTimer
+// @Summary Stop the timer on a task
+// @Description Stop the caller's running timer on the task, which becomes a work log
+// @Tags Time
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} WorkLogResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "No timer running on the task"
+// @Failure 500 {string} string "Failed to stop timer"
+// @Router /tasks/{id}/timer/stop [post]
+func (h *TimeHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("StopTimer: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	log, err := h.timeService.StopTimer(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("StopTimer: Failed to stop timer: %v\n", err)
+		http.Error(w, "Failed to stop timer", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("StopTimer: Timer stopped on task %s: %s\n", taskID.String(), uuid.UUID(log.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toWorkLogResponse(log, time.Now()))
+}
+
+// ListWorkLogs
+// @Summary Get the time logged on a task
+// @Description Retrieve the task's work logs, oldest first, with the total of the finished ones. Running timers are included with the time so far.
+// @Tags Time
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} TaskWorkLogsResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 404 {string} string "Task not found"
+// @Failure 500 {string} string "Failed to list work logs"
+// @Router /tasks/{id}/work-logs [get]
+func (h *TimeHandler) ListWorkLogs(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("ListWorkLogs: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.timeService.ListWorkLogs(r.Context(), pgt.UUID{Bytes: taskID, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("ListWorkLogs: Failed to list work logs: %v\n", err)
+		http.Error(w, "Failed to list work logs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskWorkLogsResponse(logs, time.Now()))
+}
+
+// CreateWorkLog
+// @Summary Log time on a task
+// @Description Record time the caller spent on the task without a timer. started_at defaults to the given number of minutes ago.
+// @Tags Time
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param workLog body CreateWorkLogRequest true "Work log request"
+// @Success 201 {object} WorkLogResponse
+// @Failure 400 {string} string "Invalid task ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found"
+// @Failure 422 {string} string "Invalid duration or start time"
+// @Failure 500 {string} string "Failed to log work"
+// @Router /tasks/{id}/work-logs [post]
+func (h *TimeHandler) CreateWorkLog(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("CreateWorkLog: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody CreateWorkLogRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("CreateWorkLog: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var startedAt time.Time
+	if reqBody.StartedAt != nil {
+		startedAt, err = time.Parse(time.RFC3339, *reqBody.StartedAt)
+		if err != nil {
+			fmt.Printf("CreateWorkLog: Invalid StartedAt: %v\n", err)
+			http.Error(w, "Invalid StartedAt format, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+	}
+	var note pgt.Text
+	if reqBody.Note != nil {
+		note = pgt.Text{String: *reqBody.Note, Valid: true}
+	}
+
+	log, err := h.timeService.LogWork(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, startedAt, time.Duration(reqBody.Minutes)*time.Minute, note)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateWorkLog: Failed to log work: %v\n", err)
+		http.Error(w, "Failed to log work", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CreateWorkLog: Work logged on task %s: %s\n", taskID.String(), uuid.UUID(log.ID.Bytes).String())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toWorkLogResponse(log, time.Now()))
+}
+
+// DeleteWorkLog
+// @Summary Delete a work log
+// @Description Delete time logged on a task. Only the user who logged it and admins may delete it.
+// @Tags Time
+// @Param id path string true "Task ID"
+// @Param logId path string true "Work log ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 403 {string} string "Not allowed for the caller"
+// @Failure 404 {string} string "Work log not found"
+// @Failure 500 {string} string "Failed to delete work log"
+// @Router /tasks/{id}/work-logs/{logId} [delete]
+func (h *TimeHandler) DeleteWorkLog(w http.ResponseWriter, r *http.Request) {
+	taskID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("DeleteWorkLog: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	logID, err := uuid.Parse(r.PathValue("logId"))
+	if err != nil {
+		fmt.Printf("DeleteWorkLog: Invalid work log ID: %v\n", err)
+		http.Error(w, "Invalid work log ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.timeService.DeleteWorkLog(r.Context(), pgt.UUID{Bytes: taskID, Valid: true}, pgt.UUID{Bytes: logID, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Work log not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("DeleteWorkLog: Failed to delete work log: %v\n", err)
+		http.Error(w, "Failed to delete work log", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("DeleteWorkLog: Work log deleted successfully: %s\n", logID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTimeReport
+// @Summary Report the time logged
+// @Description Total the finished work logs that started in [from, to) per task, feature and user. Use format=csv to export one line per work log, for invoicing; names and notes that would start a spreadsheet formula are prefixed with a quote.
+// @Tags Time
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "Start of the period (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string true "End of the period, exclusive (RFC 3339 or YYYY-MM-DD)"
+// @Param feature_id query string false "Only time logged on this feature's tasks"
+// @Param user_id query string false "Only time logged by this user"
+// @Param format query string false "Response format: json (default) or csv"
+// @Success 200 {object} TimeReportResponse
+// @Failure 400 {string} string "Invalid or missing parameters"
+// @Failure 500 {string} string "Failed to build time report"
+// @Router /reports/time [get]
+func (h *TimeHandler) GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from, err := parseTimeQuery(query, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeQuery(query, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.Valid || !to.Valid {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+	featureID, err := parseUUIDQuery(query, "feature_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	userID, err := parseUUIDQuery(query, "user_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.timeService.TimeReport(r.Context(), from.Time, to.Time, featureID, userID)
+	if errors.Is(err, services.ErrInvalidInput) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetTimeReport: Failed to build time report: %v\n", err)
+		http.Error(w, "Failed to build time report", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "csv" {
+		filename := fmt.Sprintf("time-report-%s-%s.csv", from.Time.Format(time.DateOnly), to.Time.Format(time.DateOnly))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		if err := report.WriteCSV(w); err != nil {
+			fmt.Printf("GetTimeReport: Failed to write CSV: %v\n", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTimeReportResponse(report))
+}
@@ -2,18 +2,24 @@ package httphandler
 
 import (
 	"encoding/json"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
 	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/core/services"
 )
 
-func toFeatureResponse(feature db.Feature) FeatureResponse {
+func toFeatureResponse(feature db.Feature, details services.FeatureDetails) FeatureResponse {
 	response := FeatureResponse{
 		ID:        uuid.UUID(feature.ID.Bytes).String(),
 		Name:      feature.Name,
 		CreatedAt: feature.CreatedAt.Time.Format(time.RFC3339),
 		UpdatedAt: feature.UpdatedAt.Time.Format(time.RFC3339),
+		Owners:    make([]FeatureOwnerResponse, len(details.Owners)),
+		Labels:    toLabelResponses(details.Labels),
 	}
 	if feature.Description.Valid {
 		response.Description = &feature.Description.String
@@ -28,16 +34,87 @@ func toFeatureResponse(feature db.Feature) FeatureResponse {
 	if feature.Status.Valid {
 		response.Status = &feature.Status.String
 	}
+	if feature.DeletedAt.Valid {
+		deletedAt := feature.DeletedAt.Time.Format(time.RFC3339)
+		response.DeletedAt = &deletedAt
+	}
+	if feature.Repos != nil {
+		response.Repos = json.RawMessage(feature.Repos)
+	}
+	response.TargetDate = formatDate(feature.TargetDate)
+	for i, owner := range details.Owners {
+		response.Owners[i] = toFeatureOwnerResponse(owner)
+	}
+	response.Progress = FeatureProgressResponse{
+		TotalTasks:      details.Progress.Total,
+		ByStatus:        details.Progress.ByStatus,
+		PercentDone:     details.Progress.Percent(),
+		PointsDone:      details.Progress.PointsDone,
+		PointsRemaining: details.Progress.PointsRemaining,
+	}
+	if response.Progress.ByStatus == nil {
+		response.Progress.ByStatus = map[string]int64{}
+	}
 	return response
 }
 
-func toTaskResponse(task db.Task) TaskResponse {
+func toFeatureOwnerResponse(owner db.FeatureOwner) FeatureOwnerResponse {
+	response := FeatureOwnerResponse{
+		ID:        uuid.UUID(owner.ID.Bytes).String(),
+		FeatureID: uuid.UUID(owner.FeatureID.Bytes).String(),
+		UserID:    uuid.UUID(owner.UserID.Bytes).String(),
+	}
+	if owner.UserName.Valid {
+		response.UserName = &owner.UserName.String
+	}
+	if owner.UserRole.Valid {
+		response.UserRole = &owner.UserRole.String
+	}
+	return response
+}
+
+func toTaskResponse(task db.Task, details services.TaskDetails) TaskResponse {
 	response := TaskResponse{
 		ID:        uuid.UUID(task.ID.Bytes).String(),
 		Name:      task.Name,
 		CreatedAt: task.CreatedAt.Time.Format(time.RFC3339),
 		UpdatedAt: task.UpdatedAt.Time.Format(time.RFC3339),
 		FeatureID: uuid.UUID(task.FeatureID.Bytes).String(),
+		BlockedBy: []TaskRef{},
+		Blocks:    []TaskRef{},
+		Labels:    toLabelResponses(details.Labels),
+		Assignees: make([]AssigneeResponse, len(details.Assignees)),
+	}
+	for i, user := range details.Assignees {
+		response.Assignees[i] = AssigneeResponse{
+			ID:   uuid.UUID(user.ID.Bytes).String(),
+			Name: user.Name,
+			Role: user.Role,
+		}
+	}
+	if task.ParentTaskID.Valid {
+		parentTaskID := uuid.UUID(task.ParentTaskID.Bytes).String()
+		response.ParentTaskID = &parentTaskID
+	}
+	response.StartDate = formatDate(task.StartDate)
+	response.DueDate = formatDate(task.DueDate)
+	if task.EstimatePoints.Valid {
+		response.EstimatePoints = &task.EstimatePoints.Int32
+	}
+	response.Overdue = services.TaskOverdue(task, time.Now())
+	if details.Subtasks != nil {
+		response.SubtaskProgress = &SubtaskProgressResponse{
+			Total:   details.Subtasks.Total,
+			Done:    details.Subtasks.Done,
+			Percent: details.Subtasks.Percent(),
+		}
+	}
+	for _, link := range details.Dependencies {
+		if link.BlockedID == task.ID {
+			response.BlockedBy = append(response.BlockedBy, toTaskRef(link.BlockerID, link.BlockerName, link.BlockerStatus))
+		} else {
+			response.Blocks = append(response.Blocks, toTaskRef(link.BlockedID, link.BlockedName, link.BlockedStatus))
+		}
 	}
 	if task.Description.Valid {
 		response.Description = &task.Description.String
@@ -58,5 +135,330 @@ func toTaskResponse(task db.Task) TaskResponse {
 	if task.GitData != nil {
 		response.GitData = json.RawMessage(task.GitData)
 	}
+	if task.DeletedAt.Valid {
+		deletedAt := task.DeletedAt.Time.Format(time.RFC3339)
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+func toUserResponse(user db.User) UserResponse {
+	response := UserResponse{
+		ID:        uuid.UUID(user.ID.Bytes).String(),
+		Name:      user.Name,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt: user.UpdatedAt.Time.Format(time.RFC3339),
+	}
+	if user.CreatedBy.Valid {
+		createdBy := uuid.UUID(user.CreatedBy.Bytes).String()
+		response.CreatedBy = &createdBy
+	}
 	return response
 }
+
+func toSearchResultResponse(result db.SearchRow) SearchResultResponse {
+	response := SearchResultResponse{
+		Type:    result.Kind,
+		ID:      uuid.UUID(result.ID.Bytes).String(),
+		Name:    result.NameHighlight,
+		Snippet: result.Snippet,
+		Rank:    result.Rank,
+	}
+	if result.FeatureID.Valid {
+		featureID := uuid.UUID(result.FeatureID.Bytes).String()
+		response.FeatureID = &featureID
+	}
+	return response
+}
+
+func toEntityWorkflowResponse(workflow domain.Workflow) EntityWorkflowResponse {
+	response := EntityWorkflowResponse{
+		InitialStatus: workflow.InitialStatus,
+		Statuses:      workflow.Statuses,
+		Transitions:   make(map[string][]string, len(workflow.Statuses)),
+		Priorities:    workflow.Priorities,
+	}
+	// Every status gets an entry, so the UI can look up next states directly
+	for _, status := range workflow.Statuses {
+		response.Transitions[status] = workflow.NextStatuses(status)
+		if response.Transitions[status] == nil {
+			response.Transitions[status] = []string{}
+		}
+	}
+	return response
+}
+
+func toAuditEventResponse(event db.AuditEvent) AuditEventResponse {
+	response := AuditEventResponse{
+		ID:         uuid.UUID(event.ID.Bytes).String(),
+		EntityType: event.EntityType,
+		EntityID:   uuid.UUID(event.EntityID.Bytes).String(),
+		Action:     event.Action,
+		Changes:    json.RawMessage(event.Changes),
+		CreatedAt:  event.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if event.ActorID.Valid {
+		actorID := uuid.UUID(event.ActorID.Bytes).String()
+		response.ActorID = &actorID
+	}
+	return response
+}
+
+func toAgentRunResponse(run db.AgentRun) AgentRunResponse {
+	response := AgentRunResponse{
+		ID:          uuid.UUID(run.ID.Bytes).String(),
+		TaskID:      uuid.UUID(run.TaskID.Bytes).String(),
+		Status:      run.Status,
+		Attempts:    run.Attempts,
+		MaxAttempts: run.MaxAttempts,
+		RunAfter:    run.RunAfter.Time.Format(time.RFC3339),
+		Logs:        run.Logs,
+		CreatedAt:   run.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt:   run.UpdatedAt.Time.Format(time.RFC3339),
+	}
+	if run.Provider.Valid {
+		response.Provider = &run.Provider.String
+	}
+	if run.Model.Valid {
+		response.Model = &run.Model.String
+	}
+	if run.Prompt.Valid {
+		response.Prompt = &run.Prompt.String
+	}
+	if run.Output.Valid {
+		response.Output = &run.Output.String
+	}
+	if run.Error.Valid {
+		response.Error = &run.Error.String
+	}
+	if run.CreatedBy.Valid {
+		createdBy := uuid.UUID(run.CreatedBy.Bytes).String()
+		response.CreatedBy = &createdBy
+	}
+	if run.StartedAt.Valid {
+		startedAt := run.StartedAt.Time.Format(time.RFC3339)
+		response.StartedAt = &startedAt
+	}
+	if run.FinishedAt.Valid {
+		finishedAt := run.FinishedAt.Time.Format(time.RFC3339)
+		response.FinishedAt = &finishedAt
+	}
+	return response
+}
+
+func toAPITokenResponse(token db.ApiToken) APITokenResponse {
+	response := APITokenResponse{
+		ID:        uuid.UUID(token.ID.Bytes).String(),
+		Name:      token.Name,
+		CreatedAt: token.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if token.ExpiresAt.Valid {
+		expiresAt := token.ExpiresAt.Time.Format(time.RFC3339)
+		response.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt.Valid {
+		lastUsedAt := token.LastUsedAt.Time.Format(time.RFC3339)
+		response.LastUsedAt = &lastUsedAt
+	}
+	if token.RevokedAt.Valid {
+		revokedAt := token.RevokedAt.Time.Format(time.RFC3339)
+		response.RevokedAt = &revokedAt
+	}
+	return response
+}
+
+func toCommentResponse(comment db.TaskComment) CommentResponse {
+	response := CommentResponse{
+		ID:         uuid.UUID(comment.ID.Bytes).String(),
+		TaskID:     uuid.UUID(comment.TaskID.Bytes).String(),
+		AuthorName: comment.AuthorName,
+		Body:       comment.Body,
+		CreatedAt:  comment.CreatedAt.Time.Format(time.RFC3339),
+		Replies:    []CommentResponse{},
+	}
+	if comment.ParentID.Valid {
+		parentID := uuid.UUID(comment.ParentID.Bytes).String()
+		response.ParentID = &parentID
+	}
+	if comment.AuthorID.Valid {
+		authorID := uuid.UUID(comment.AuthorID.Bytes).String()
+		response.AuthorID = &authorID
+	}
+	if comment.AgentRunID.Valid {
+		agentRunID := uuid.UUID(comment.AgentRunID.Bytes).String()
+		response.AgentRunID = &agentRunID
+	}
+	if comment.EditedAt.Valid {
+		editedAt := comment.EditedAt.Time.Format(time.RFC3339)
+		response.EditedAt = &editedAt
+	}
+	return response
+}
+
+// toCommentThreads nests replies under the comments they answer. comments are
+// in posting order, so every thread stays oldest first.
+func toCommentThreads(comments []db.TaskComment) []CommentResponse {
+	children := make(map[pgt.UUID][]db.TaskComment)
+	var roots []db.TaskComment
+	for _, comment := range comments {
+		if comment.ParentID.Valid {
+			children[comment.ParentID] = append(children[comment.ParentID], comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+
+	var build func(comment db.TaskComment) CommentResponse
+	build = func(comment db.TaskComment) CommentResponse {
+		response := toCommentResponse(comment)
+		for _, reply := range children[comment.ID] {
+			response.Replies = append(response.Replies, build(reply))
+		}
+		return response
+	}
+
+	threads := make([]CommentResponse, len(roots))
+	for i, root := range roots {
+		threads[i] = build(root)
+	}
+	return threads
+}
+
+func toTaskRef(id pgt.UUID, name string, status pgt.Text) TaskRef {
+	ref := TaskRef{ID: uuid.UUID(id.Bytes).String(), Name: name}
+	if status.Valid {
+		ref.Status = &status.String
+	}
+	return ref
+}
+
+func toDependencyGraphResponse(featureID string, graph domain.DependencyGraph) DependencyGraphResponse {
+	response := DependencyGraphResponse{
+		FeatureID: featureID,
+		Nodes:     make([]DependencyNodeResponse, len(graph.Nodes)),
+		Edges:     make([]DependencyEdgeResponse, len(graph.Edges)),
+		DOT:       graph.DOT(),
+	}
+	for i, node := range graph.Nodes {
+		response.Nodes[i] = DependencyNodeResponse{
+			ID:        node.ID,
+			Name:      node.Name,
+			FeatureID: node.FeatureID,
+			External:  node.External,
+		}
+		if node.Status != "" {
+			status := node.Status
+			response.Nodes[i].Status = &status
+		}
+	}
+	for i, edge := range graph.Edges {
+		response.Edges[i] = DependencyEdgeResponse{From: edge.From, To: edge.To}
+	}
+	return response
+}
+
+// toTaskTreeResponse nests subtasks under their parents, starting at task.
+// subtasks are in creation order, so every level stays oldest first.
+func toTaskTreeResponse(task db.Task, subtasks []db.Task, details map[pgt.UUID]services.TaskDetails) TaskResponse {
+	children := make(map[pgt.UUID][]db.Task)
+	for _, subtask := range subtasks {
+		children[subtask.ParentTaskID] = append(children[subtask.ParentTaskID], subtask)
+	}
+
+	var build func(task db.Task) TaskResponse
+	build = func(task db.Task) TaskResponse {
+		response := toTaskResponse(task, details[task.ID])
+		for _, child := range children[task.ID] {
+			response.Subtasks = append(response.Subtasks, build(child))
+		}
+		return response
+	}
+	return build(task)
+}
+
+func toLabelResponse(label db.Label) LabelResponse {
+	response := LabelResponse{
+		ID:        uuid.UUID(label.ID.Bytes).String(),
+		Name:      label.Name,
+		Color:     label.Color,
+		CreatedAt: label.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt: label.UpdatedAt.Time.Format(time.RFC3339),
+	}
+	if label.CreatedBy.Valid {
+		createdBy := uuid.UUID(label.CreatedBy.Bytes).String()
+		response.CreatedBy = &createdBy
+	}
+	return response
+}
+
+// toLabelResponses always returns a slice, so unlabelled tasks and features
+// have "labels": [].
+func toLabelResponses(labels []db.Label) []LabelResponse {
+	responses := make([]LabelResponse, len(labels))
+	for i, label := range labels {
+		responses[i] = toLabelResponse(label)
+	}
+	return responses
+}
+
+func toWorkLogResponse(log db.WorkLog, now time.Time) WorkLogResponse {
+	response := WorkLogResponse{
+		ID:        uuid.UUID(log.ID.Bytes).String(),
+		TaskID:    uuid.UUID(log.TaskID.Bytes).String(),
+		UserName:  log.UserName,
+		StartedAt: log.StartedAt.Time.Format(time.RFC3339),
+		Minutes:   domain.Minutes(services.WorkLogDuration(log, now)),
+		Running:   !log.EndedAt.Valid,
+		CreatedAt: log.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if log.UserID.Valid {
+		userID := uuid.UUID(log.UserID.Bytes).String()
+		response.UserID = &userID
+	}
+	if log.EndedAt.Valid {
+		endedAt := log.EndedAt.Time.Format(time.RFC3339)
+		response.EndedAt = &endedAt
+	}
+	if log.Note.Valid {
+		response.Note = &log.Note.String
+	}
+	return response
+}
+
+func toTaskWorkLogsResponse(logs []db.WorkLog, now time.Time) TaskWorkLogsResponse {
+	response := TaskWorkLogsResponse{Items: make([]WorkLogResponse, len(logs))}
+	var total time.Duration
+	for i, log := range logs {
+		response.Items[i] = toWorkLogResponse(log, now)
+		if log.EndedAt.Valid {
+			total += services.WorkLogDuration(log, now)
+		}
+	}
+	response.TotalMinutes = domain.Minutes(total)
+	return response
+}
+
+func toTimeReportResponse(report domain.TimeReport) TimeReportResponse {
+	return TimeReportResponse{
+		From:         report.From.Format(time.RFC3339),
+		To:           report.To.Format(time.RFC3339),
+		TotalMinutes: domain.Minutes(report.Total),
+		ByTask:       toTimeTotalResponses(report.ByTask),
+		ByFeature:    toTimeTotalResponses(report.ByFeature),
+		ByUser:       toTimeTotalResponses(report.ByUser),
+	}
+}
+
+func toTimeTotalResponses(totals []domain.TimeTotal) []TimeTotalResponse {
+	responses := make([]TimeTotalResponse, len(totals))
+	for i, total := range totals {
+		responses[i] = TimeTotalResponse{
+			ID:      total.ID,
+			Name:    total.Name,
+			Minutes: domain.Minutes(total.Duration),
+			Hours:   math.Round(total.Duration.Hours()*100) / 100,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,157 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/services"
+)
+
+type TrashHandler struct {
+	trashService   *services.TrashService
+	featureService *services.FeatureService
+}
+
+func NewTrashHandler(trashService *services.TrashService, featureService *services.FeatureService) *TrashHandler {
+	return &TrashHandler{trashService: trashService, featureService: featureService}
+}
+
+// ListTrash
+// @Summary Get the trash
+// @Description Retrieve deleted tasks and features that have not been purged yet, most recently deleted first
+// @Tags Trash
+// @Produce json
+// @Success 200 {object} TrashResponse
+// @Failure 500 {string} string "Failed to list trash"
+// @Router /trash [get]
+func (h *TrashHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	tasks, features, err := h.trashService.ListTrash(r.Context())
+	if err != nil {
+		fmt.Printf("ListTrash: Failed to list trash: %v\n", err)
+		http.Error(w, "Failed to list trash", http.StatusInternalServerError)
+		return
+	}
+
+	featureIDs := make([]pgt.UUID, len(features))
+	for i, feature := range features {
+		featureIDs[i] = feature.ID
+	}
+	details, err := h.featureService.ListFeatureDetails(r.Context(), featureIDs)
+	if err != nil {
+		fmt.Printf("ListTrash: Failed to load feature details: %v\n", err)
+		http.Error(w, "Failed to list trash", http.StatusInternalServerError)
+		return
+	}
+
+	response := TrashResponse{
+		Tasks:    make([]TaskResponse, len(tasks)),
+		Features: make([]FeatureResponse, len(features)),
+	}
+	for i, task := range tasks {
+		response.Tasks[i] = toTaskResponse(task, services.TaskDetails{})
+	}
+	for i, feature := range features {
+		response.Features[i] = toFeatureResponse(feature, details[feature.ID])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreTask
+// @Summary Restore a deleted task
+// @Description Take a task out of the trash, with the subtasks deleted along with it. Its feature and parent task must not be deleted.
+// @Tags Trash
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} TaskResponse
+// @Failure 400 {string} string "Invalid task ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Task not found in trash"
+// @Failure 409 {string} string "The task's feature is deleted"
+// @Failure 500 {string} string "Failed to restore task"
+// @Router /tasks/{id}/restore [post]
+func (h *TrashHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RestoreTask: Invalid task ID: %v\n", err)
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.trashService.RestoreTask(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Task not found in trash", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RestoreTask: Failed to restore task: %v\n", err)
+		http.Error(w, "Failed to restore task", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RestoreTask: Task restored successfully: %s\n", id.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTaskResponse(task, services.TaskDetails{}))
+}
+
+// RestoreFeature
+// @Summary Restore a deleted feature
+// @Description Take a feature out of the trash. Tasks deleted together with it (DELETE /features/{id}?mode=cascade) are restored too; tasks deleted on their own before that stay in the trash.
+// @Tags Trash
+// @Produce json
+// @Param id path string true "Feature ID"
+// @Success 200 {object} FeatureResponse
+// @Failure 400 {string} string "Invalid feature ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "Feature not found in trash"
+// @Failure 500 {string} string "Failed to restore feature"
+// @Router /features/{id}/restore [post]
+func (h *TrashHandler) RestoreFeature(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Printf("RestoreFeature: Invalid feature ID: %v\n", err)
+		http.Error(w, "Invalid feature ID", http.StatusBadRequest)
+		return
+	}
+
+	feature, err := h.trashService.RestoreFeature(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "Feature not found in trash", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("RestoreFeature: Failed to restore feature: %v\n", err)
+		http.Error(w, "Failed to restore feature", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.featureService.GetFeatureDetails(r.Context(), feature.ID)
+	if err != nil {
+		fmt.Printf("RestoreFeature: Failed to load feature details: %v\n", err)
+		http.Error(w, "Failed to restore feature", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("RestoreFeature: Feature restored successfully: %s\n", id.String())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toFeatureResponse(feature, details))
+}
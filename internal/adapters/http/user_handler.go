@@ -0,0 +1,271 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/services"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+}
+
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{userService: userService}
+}
+
+// CreateUser
+// @Summary Create a new user
+// @Description Create a new user with the provided details. Only admins manage users; the role is viewer, member or admin.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user body CreateUserRequest true "User creation request"
+// @Success 201 {object} UserResponse
+// @Failure 400 {string} string "Invalid request body or format"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 422 {string} string "Unknown role"
+// @Failure 500 {string} string "Failed to create user"
+// @Router /users [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var reqBody CreateUserRequest
+
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("CreateUser: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	fmt.Printf("CreateUser: Decoded request body: %+v\n", reqBody)
+
+	if reqBody.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if reqBody.Role == "" {
+		http.Error(w, "Role is required", http.StatusBadRequest)
+		return
+	}
+
+	arg := db.CreateUserParams{
+		Name: reqBody.Name,
+		Role: reqBody.Role,
+	}
+
+	user, err := h.userService.CreateUser(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("CreateUser: Failed to create user: %v\n", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("CreateUser: User created successfully: %+v\n", user)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+// ListUsers
+// @Summary Get all users
+// @Description Retrieve a list of all users
+// @Tags Users
+// @Produce json
+// @Success 200 {array} UserResponse
+// @Failure 500 {string} string "Failed to list users"
+// @Router /users [get]
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("ListUsers: Calling service to list users")
+
+	users, err := h.userService.ListUsers(r.Context())
+	if err != nil {
+		fmt.Printf("ListUsers: Failed to list users: %v\n", err)
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("ListUsers: Successfully listed %d users\n", len(users))
+
+	userResponses := make([]UserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = toUserResponse(user)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponses)
+}
+
+// GetUser
+// @Summary Get a user
+// @Description Retrieve a single user by its ID
+// @Tags Users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Failed to get user"
+// @Router /users/{id} [get]
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
+	if idStr == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		fmt.Printf("GetUser: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetUser(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GetUser: Failed to get user: %v\n", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+// UpdateUser
+// @Summary Update an existing user
+// @Description Update an existing user with the provided details. Only admins manage users.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body UpdateUserRequest true "User update request"
+// @Success 200 {object} UserResponse
+// @Failure 400 {string} string "Invalid user ID or request body"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 404 {string} string "User not found"
+// @Failure 422 {string} string "Unknown role, or a role change for the agent user"
+// @Failure 500 {string} string "Failed to update user"
+// @Router /users/{id} [put]
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
+	if idStr == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		fmt.Printf("UpdateUser: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var reqBody UpdateUserRequest
+
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		fmt.Printf("UpdateUser: Invalid request body: %v\n", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	fmt.Printf("UpdateUser: Decoded request body: %+v\n", reqBody)
+
+	arg := db.UpdateUserParams{
+		ID: pgt.UUID{Bytes: id, Valid: true},
+	}
+
+	if reqBody.Name != nil {
+		arg.Name = pgt.Text{String: *reqBody.Name, Valid: true}
+	}
+	if reqBody.Role != nil {
+		arg.Role = pgt.Text{String: *reqBody.Role, Valid: true}
+	}
+
+	user, err := h.userService.UpdateUser(r.Context(), arg)
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Printf("UpdateUser: Failed to update user: %v\n", err)
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("UpdateUser: User updated successfully: %+v\n", user)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toUserResponse(user))
+}
+
+// DeleteUser
+// @Summary Delete a user
+// @Description Delete a user by its ID. Only admins manage users.
+// @Tags Users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 403 {string} string "Not allowed for the caller's role"
+// @Failure 409 {string} string "The agent user can't be deleted, or the user still owns features"
+// @Failure 500 {string} string "Failed to delete user"
+// @Router /users/{id} [delete]
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
+	if idStr == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		fmt.Printf("DeleteUser: Invalid user ID: %v\n", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.userService.DeleteUser(r.Context(), pgt.UUID{Bytes: id, Valid: true})
+	if errors.Is(err, services.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("DeleteUser: Failed to delete user: %v\n", err)
+		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("DeleteUser: User deleted successfully: %s\n", id.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
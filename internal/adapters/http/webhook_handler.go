@@ -0,0 +1,100 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/adapters/github"
+	"shelke.dev/api/internal/core/services"
+	"shelke.dev/api/internal/ports"
+)
+
+// maxWebhookPayload is the largest payload GitHub delivers.
+const maxWebhookPayload = 25 << 20
+
+type WebhookHandler struct {
+	pullRequestService *services.PullRequestService
+	githubSecret       []byte
+}
+
+func NewWebhookHandler(pullRequestService *services.PullRequestService, githubSecret string) *WebhookHandler {
+	return &WebhookHandler{pullRequestService: pullRequestService, githubSecret: []byte(githubSecret)}
+}
+
+// GitHubWebhook
+// @Summary Receive a GitHub webhook
+// @Description Sync pull_request, pull_request_review and push events to the tasks they refer to, matched by a task/{id} branch, a task ID in the pull request title or a branch linked in git_data. Opening a pull request moves its tasks to review, merging it to done, requesting changes back to in progress, and pushing commits starts tasks that are still in the initial status. Deliveries must be signed with the secret in GITHUB_WEBHOOK_SECRET; other events are acknowledged and ignored.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param X-GitHub-Event header string true "Event type"
+// @Param X-Hub-Signature-256 header string true "HMAC-SHA256 signature of the body"
+// @Success 200 {object} WebhookResponse
+// @Success 204 {string} string "Event ignored"
+// @Failure 400 {string} string "Invalid payload"
+// @Failure 401 {string} string "Invalid signature"
+// @Failure 503 {string} string "Webhook secret not configured"
+// @Failure 500 {string} string "Failed to sync event"
+// @Router /webhooks/github [post]
+func (h *WebhookHandler) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if len(h.githubSecret) == 0 {
+		fmt.Printf("GitHubWebhook: GITHUB_WEBHOOK_SECRET is not set, rejecting delivery\n")
+		http.Error(w, "Webhook secret not configured", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		fmt.Printf("GitHubWebhook: Failed to read payload: %v\n", err)
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	if err := github.VerifySignature(h.githubSecret, body, r.Header.Get("X-Hub-Signature-256")); err != nil {
+		fmt.Printf("GitHubWebhook: %v\n", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := r.Header.Get("X-GitHub-Event")
+	event, err := github.ParseWebhook(eventType, body)
+	if err != nil {
+		fmt.Printf("GitHubWebhook: %v\n", err)
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	var tasks []db.Task
+	switch event := event.(type) {
+	case ports.PullRequestEvent:
+		tasks, err = h.pullRequestService.SyncPullRequest(r.Context(), event)
+	case ports.PushEvent:
+		tasks, err = h.pullRequestService.SyncPush(r.Context(), event)
+	default:
+		fmt.Printf("GitHubWebhook: Ignoring %q event\n", eventType)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if errors.Is(err, services.ErrValidation) {
+		fmt.Printf("GitHubWebhook: %v\n", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		fmt.Printf("GitHubWebhook: Failed to sync %s event: %v\n", eventType, err)
+		http.Error(w, "Failed to sync event", http.StatusInternalServerError)
+		return
+	}
+
+	taskIDs := make([]string, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = uuid.UUID(task.ID.Bytes).String()
+	}
+	fmt.Printf("GitHubWebhook: Synced %s event to tasks %v\n", eventType, taskIDs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(WebhookResponse{Event: eventType, Tasks: taskIDs})
+}
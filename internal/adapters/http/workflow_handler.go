@@ -0,0 +1,34 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"shelke.dev/api/internal/ports"
+)
+
+type WorkflowHandler struct {
+	workflowService ports.WorkflowService
+}
+
+func NewWorkflowHandler(workflowService ports.WorkflowService) *WorkflowHandler {
+	return &WorkflowHandler{workflowService: workflowService}
+}
+
+// GetWorkflow
+// @Summary Get the status workflow
+// @Description Retrieve the allowed statuses, transitions and priorities for tasks and features, and whether features are completed with their tasks
+// @Tags Workflow
+// @Produce json
+// @Success 200 {object} WorkflowResponse
+// @Router /workflow [get]
+func (h *WorkflowHandler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflows := h.workflowService.Workflows()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(WorkflowResponse{
+		Task:                toEntityWorkflowResponse(workflows.Task),
+		Feature:             toEntityWorkflowResponse(workflows.Feature),
+		DeriveFeatureStatus: workflows.DeriveFeatureStatus,
+	})
+}
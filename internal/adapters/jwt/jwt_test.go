@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func newTestSigner(key string) *Signer {
+	s := NewSigner([]byte(key))
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func encode(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// signWith builds a token from raw header and payload JSON, signed with key.
+func signWith(key, headerJSON, payloadJSON string) string {
+	signingInput := encode(headerJSON) + "." + encode(payloadJSON)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	s := newTestSigner("secret")
+	token, err := s.Issue("user-1", testNow.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	subject, err := s.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if subject != "user-1" {
+		t.Errorf("subject = %q, want user-1", subject)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	s := newTestSigner("secret")
+	valid, err := s.Issue("user-1", testNow.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+	exp := strconv.FormatInt(testNow.Add(time.Hour).Unix(), 10)
+	payload := `{"sub":"user-1","iat":1,"exp":` + exp + `}`
+
+	expired, err := s.Issue("user-1", testNow)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	otherKey, err := newTestSigner("other secret").Issue("user-1", testNow.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"tampered payload", parts[0] + "." + encode(`{"sub":"admin","iat":1,"exp":`+exp+`}`) + "." + parts[2]},
+		{"HS512 header", signWith("secret", `{"alg":"HS512","typ":"JWT"}`, payload)},
+		{"reordered header", signWith("secret", `{"typ":"JWT","alg":"HS256"}`, payload)},
+		{"alg none", encode(`{"alg":"none","typ":"JWT"}`) + "." + encode(payload) + "."},
+		{"expired", expired},
+		{"wrong key", otherKey},
+		{"empty subject", signWith("secret", `{"alg":"HS256","typ":"JWT"}`, `{"sub":"","exp":`+exp+`}`)},
+		{"missing signature", parts[0] + "." + parts[1]},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!!!"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := s.Verify(tt.token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify = %q, %v, want ErrInvalidToken", subject, err)
+			}
+		})
+	}
+}
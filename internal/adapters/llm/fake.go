@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"shelke.dev/api/internal/ports"
+)
+
+// Fake is a deterministic LLMProvider for tests and local development. It
+// replays the configured responses in order, repeating the last one, and
+// records every request it receives. Without responses it answers with a
+// summary that only depends on the prompt.
+type Fake struct {
+	mu        sync.Mutex
+	responses []string
+	requests  []ports.LLMRequest
+	// Err, when set, is returned by Generate instead of a response.
+	Err error
+}
+
+func NewFake(responses ...string) *Fake {
+	return &Fake{responses: responses}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Generate(ctx context.Context, req ports.LLMRequest) (ports.LLMResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, req)
+	if f.Err != nil {
+		return ports.LLMResponse{}, f.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return ports.LLMResponse{}, err
+	}
+
+	if len(f.responses) == 0 {
+		sum := sha256.Sum256([]byte(req.SystemPrompt + "\n" + req.Prompt))
+		return ports.LLMResponse{
+			Text:  fmt.Sprintf("Fake response %s. No changes were made.", hex.EncodeToString(sum[:8])),
+			Model: "fake",
+		}, nil
+	}
+	i := min(len(f.requests), len(f.responses)) - 1
+	return ports.LLMResponse{Text: f.responses[i], Model: "fake"}, nil
+}
+
+// Requests returns the requests received so far.
+func (f *Fake) Requests() []ports.LLMRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ports.LLMRequest(nil), f.requests...)
+}
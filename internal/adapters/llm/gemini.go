@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"shelke.dev/api/internal/ports"
+)
+
+const (
+	// DefaultGeminiModel is used when no model is configured.
+	DefaultGeminiModel   = "gemini-2.5-flash"
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+// Gemini talks to the Google Gemini generateContent REST API.
+type Gemini struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewGemini returns a Gemini provider. An empty model selects
+// DefaultGeminiModel.
+func NewGemini(apiKey, model string) *Gemini {
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+	return &Gemini{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: defaultGeminiBaseURL,
+		client:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (g *Gemini) Name() string {
+	return "gemini"
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"system_instruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	ModelVersion string `json:"modelVersion"`
+	Error        *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// Generate sends the prompt as a single user turn and returns the text of the
+// first candidate.
+func (g *Gemini) Generate(ctx context.Context, req ports.LLMRequest) (ports.LLMResponse, error) {
+	if g.apiKey == "" {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: GEMINI_API_KEY is not set")
+	}
+
+	body := geminiRequest{
+		Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: req.Prompt}}}},
+	}
+	if req.SystemPrompt != "" {
+		body.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: req.SystemPrompt}}}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: failed to encode request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", g.baseURL, url.PathEscape(g.model))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: failed to build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", g.apiKey)
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: failed to read response: %w", err)
+	}
+	var decoded geminiResponse
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: unexpected response (status %d): %s", resp.StatusCode, truncate(string(raw), 200))
+	}
+	if decoded.Error != nil {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: %s (%d %s)", decoded.Error.Message, decoded.Error.Code, decoded.Error.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: unexpected status %d", resp.StatusCode)
+	}
+	if len(decoded.Candidates) == 0 {
+		return ports.LLMResponse{}, fmt.Errorf("gemini: response has no candidates")
+	}
+
+	var text strings.Builder
+	for _, part := range decoded.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	model := decoded.ModelVersion
+	if model == "" {
+		model = g.model
+	}
+	return ports.LLMResponse{Text: text.String(), Model: model}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyGraph is the graph of tasks blocking each other. Edges point
+// from the blocking task to the task it blocks. Cycles are rejected when
+// dependencies are added, so the graph is a DAG.
+type DependencyGraph struct {
+	Name  string
+	Nodes []DependencyNode
+	Edges []DependencyEdge
+}
+
+// DependencyNode is a task in the graph. External tasks belong to another
+// feature and are only included because they block, or are blocked by, one
+// of the feature's tasks.
+type DependencyNode struct {
+	ID        string
+	Name      string
+	Status    string
+	FeatureID string
+	External  bool
+}
+
+type DependencyEdge struct {
+	From string
+	To   string
+}
+
+// DOT renders the graph in the Graphviz DOT language, with one box per task
+// labelled with its name and status. External tasks are dashed.
+func (g DependencyGraph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		label := dotEscape(node.Name)
+		if node.Status != "" {
+			label += `\n(` + dotEscape(node.Status) + ")"
+		}
+		fmt.Fprintf(&b, "  %s [label=\"%s\"", dotQuote(node.ID), label)
+		if node.External {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+// dotEscape escapes s for use inside a quoted DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,369 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Pull request states, as reported by GitHub.
+const (
+	PullRequestOpen   = "open"
+	PullRequestClosed = "closed"
+	PullRequestMerged = "merged"
+)
+
+// GitData is the version control state linked to a task. Branches, commits
+// and pull requests refer to one of the linked repos by its "owner/name".
+type GitData struct {
+	Repos        []GitRepo        `json:"repos"`
+	Branches     []GitBranch      `json:"branches"`
+	Commits      []GitCommit      `json:"commits"`
+	PullRequests []GitPullRequest `json:"pull_requests"`
+	// Legacy holds git_data stored before it had this shape, so nothing is
+	// lost when the task is linked to git again. See ParseStoredGitData.
+	Legacy json.RawMessage `json:"legacy,omitempty"`
+}
+
+// GitRepo is a repository a task or feature works in.
+type GitRepo struct {
+	Owner         string `json:"owner"`
+	Name          string `json:"name"`
+	URL           string `json:"url,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+type GitBranch struct {
+	Repo string `json:"repo"`
+	Name string `json:"name"`
+}
+
+type GitCommit struct {
+	Repo    string `json:"repo"`
+	SHA     string `json:"sha"`
+	Branch  string `json:"branch,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type GitPullRequest struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	URL    string `json:"url,omitempty"`
+	State  string `json:"state"`
+	Branch string `json:"branch,omitempty"`
+}
+
+var (
+	repoPartPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	shaPattern      = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+	taskKeyPattern  = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+)
+
+// taskBranchPrefix starts the name of every branch the agent works on.
+const taskBranchPrefix = "task/"
+
+// TaskBranchName is the branch the agent commits a task's changes to.
+func TaskBranchName(taskID string) string {
+	return taskBranchPrefix + taskID
+}
+
+// TaskIDFromBranch returns the task ID of a branch named by TaskBranchName.
+func TaskIDFromBranch(branch string) (string, bool) {
+	id, ok := strings.CutPrefix(branch, taskBranchPrefix)
+	if !ok || taskKeyPattern.FindString(id) != id {
+		return "", false
+	}
+	return id, true
+}
+
+// TaskKeys returns the task IDs mentioned in text, such as a pull request
+// titled "Fix login (3fa85f64-5717-4562-b3fc-2c963f66afa6)".
+func TaskKeys(text string) []string {
+	return taskKeyPattern.FindAllString(text, -1)
+}
+
+// ParseGitData decodes stored or submitted git data. Unknown fields are
+// rejected so typos don't silently drop data. An empty input yields empty
+// git data.
+func ParseGitData(raw []byte) (GitData, error) {
+	var data GitData
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&data); err != nil {
+		return GitData{}, fmt.Errorf("invalid git_data: %w", err)
+	}
+	return data, nil
+}
+
+// ParseStoredGitData decodes the git data of a stored task. Data that
+// ParseGitData rejects, written before git_data had a schema, is kept in
+// Legacy of otherwise empty git data rather than failing every change to
+// the task's git data.
+func ParseStoredGitData(raw []byte) GitData {
+	data, err := ParseGitData(raw)
+	if err != nil {
+		return GitData{Legacy: bytes.Clone(raw)}
+	}
+	return data
+}
+
+// ParseRepos decodes a list of repos, rejecting unknown fields.
+func ParseRepos(raw []byte) ([]GitRepo, error) {
+	var repos []GitRepo
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return repos, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&repos); err != nil {
+		return nil, fmt.Errorf("invalid repos: %w", err)
+	}
+	return repos, nil
+}
+
+// ParseRepoFullName splits "owner/name" into its parts.
+func ParseRepoFullName(fullName string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok || !repoPartPattern.MatchString(owner) || !repoPartPattern.MatchString(name) {
+		return "", "", fmt.Errorf("repo %q must look like owner/name", fullName)
+	}
+	return owner, name, nil
+}
+
+func (r GitRepo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// RepoURLSchemes are the schemes a repo URL may use. Local paths and file://
+// URLs are left out so members can't point the agent at the server's disk.
+var RepoURLSchemes = []string{"https", "ssh"}
+
+// Validate checks the repo's fields. The URL, if any, must use one of
+// RepoURLSchemes.
+func (r GitRepo) Validate() error {
+	return r.ValidateWithSchemes(RepoURLSchemes)
+}
+
+// ValidateWithSchemes is Validate with the URL schemes given by the caller.
+func (r GitRepo) ValidateWithSchemes(schemes []string) error {
+	if !repoPartPattern.MatchString(r.Owner) || !repoPartPattern.MatchString(r.Name) {
+		return fmt.Errorf("repo %q must have an owner and a name made of letters, digits, '-', '_' and '.'", r.FullName())
+	}
+	if r.DefaultBranch != "" {
+		if err := validateBranchName(r.DefaultBranch); err != nil {
+			return err
+		}
+	}
+	if r.URL != "" {
+		if err := validateRepoURL(r.URL, schemes); err != nil {
+			return fmt.Errorf("repo %q: %w", r.FullName(), err)
+		}
+	}
+	return nil
+}
+
+// validateRepoURL checks that raw is an absolute URL with one of schemes and,
+// except for file URLs, a host. Such a URL never starts with '-', so git can't
+// read it as an option.
+func validateRepoURL(raw string, schemes []string) error {
+	if strings.HasPrefix(raw, "-") {
+		return fmt.Errorf("url must not start with '-'")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("url must be a %s URL", strings.Join(schemes, " or "))
+	}
+	if u.Scheme != "file" && u.Host == "" {
+		return fmt.Errorf("url must have a host")
+	}
+	return nil
+}
+
+// ValidateRepos checks a list of repos and that none is listed twice.
+func ValidateRepos(repos []GitRepo) error {
+	for i, repo := range repos {
+		if err := repo.Validate(); err != nil {
+			return err
+		}
+		for _, other := range repos[:i] {
+			if strings.EqualFold(other.FullName(), repo.FullName()) {
+				return fmt.Errorf("repo %q is listed twice", repo.FullName())
+			}
+		}
+	}
+	return nil
+}
+
+// Repo returns the linked repo with the given "owner/name", compared case
+// insensitively like GitHub does.
+func (d GitData) Repo(fullName string) (GitRepo, bool) {
+	for _, repo := range d.Repos {
+		if strings.EqualFold(repo.FullName(), fullName) {
+			return repo, true
+		}
+	}
+	return GitRepo{}, false
+}
+
+// Validate checks every entry and that branches, commits and pull requests
+// only refer to linked repos.
+func (d GitData) Validate() error {
+	if err := ValidateRepos(d.Repos); err != nil {
+		return err
+	}
+	for i, branch := range d.Branches {
+		if err := d.checkRepoRef(branch.Repo); err != nil {
+			return err
+		}
+		if err := validateBranchName(branch.Name); err != nil {
+			return err
+		}
+		for _, other := range d.Branches[:i] {
+			if strings.EqualFold(other.Repo, branch.Repo) && other.Name == branch.Name {
+				return fmt.Errorf("branch %q of repo %q is listed twice", branch.Name, branch.Repo)
+			}
+		}
+	}
+	for _, commit := range d.Commits {
+		if err := d.checkRepoRef(commit.Repo); err != nil {
+			return err
+		}
+		if !shaPattern.MatchString(commit.SHA) {
+			return fmt.Errorf("commit sha %q must be 7 to 64 lowercase hex characters", commit.SHA)
+		}
+		if commit.Branch != "" {
+			if err := validateBranchName(commit.Branch); err != nil {
+				return err
+			}
+		}
+	}
+	for i, pr := range d.PullRequests {
+		if err := d.checkRepoRef(pr.Repo); err != nil {
+			return err
+		}
+		if pr.Number <= 0 {
+			return fmt.Errorf("pull request number must be positive, got %d", pr.Number)
+		}
+		if !slices.Contains([]string{PullRequestOpen, PullRequestClosed, PullRequestMerged}, pr.State) {
+			return fmt.Errorf("pull request state %q must be open, closed or merged", pr.State)
+		}
+		if pr.Branch != "" {
+			if err := validateBranchName(pr.Branch); err != nil {
+				return err
+			}
+		}
+		for _, other := range d.PullRequests[:i] {
+			if strings.EqualFold(other.Repo, pr.Repo) && other.Number == pr.Number {
+				return fmt.Errorf("pull request %s#%d is listed twice", pr.Repo, pr.Number)
+			}
+		}
+	}
+	return nil
+}
+
+func (d GitData) checkRepoRef(fullName string) error {
+	if _, _, err := ParseRepoFullName(fullName); err != nil {
+		return err
+	}
+	if _, ok := d.Repo(fullName); !ok {
+		return fmt.Errorf("repo %q is not linked to the task", fullName)
+	}
+	return nil
+}
+
+// LinkRepo adds the repo unless it is already linked.
+func (d *GitData) LinkRepo(repo GitRepo) {
+	if _, ok := d.Repo(repo.FullName()); !ok {
+		d.Repos = append(d.Repos, repo)
+	}
+}
+
+// AddBranch links the branch, and its repo if needed. Adding a branch that is
+// already linked is a no-op.
+func (d *GitData) AddBranch(branch GitBranch) error {
+	owner, name, err := ParseRepoFullName(branch.Repo)
+	if err != nil {
+		return err
+	}
+	d.LinkRepo(GitRepo{Owner: owner, Name: name})
+	for _, existing := range d.Branches {
+		if strings.EqualFold(existing.Repo, branch.Repo) && existing.Name == branch.Name {
+			return nil
+		}
+	}
+	d.Branches = append(d.Branches, branch)
+	return nil
+}
+
+// RemoveBranch unlinks the branch and reports whether it was linked.
+func (d *GitData) RemoveBranch(repo, name string) bool {
+	n := len(d.Branches)
+	d.Branches = slices.DeleteFunc(d.Branches, func(b GitBranch) bool {
+		return strings.EqualFold(b.Repo, repo) && b.Name == name
+	})
+	return len(d.Branches) != n
+}
+
+// AddCommit records the commit unless it is already known.
+func (d *GitData) AddCommit(commit GitCommit) error {
+	owner, name, err := ParseRepoFullName(commit.Repo)
+	if err != nil {
+		return err
+	}
+	d.LinkRepo(GitRepo{Owner: owner, Name: name})
+	for _, existing := range d.Commits {
+		if strings.EqualFold(existing.Repo, commit.Repo) && existing.SHA == commit.SHA {
+			return nil
+		}
+	}
+	d.Commits = append(d.Commits, commit)
+	return nil
+}
+
+// UpsertPullRequest links the pull request, and its repo if needed. A pull
+// request that is already linked is replaced.
+func (d *GitData) UpsertPullRequest(pr GitPullRequest) error {
+	owner, name, err := ParseRepoFullName(pr.Repo)
+	if err != nil {
+		return err
+	}
+	d.LinkRepo(GitRepo{Owner: owner, Name: name})
+	for i, existing := range d.PullRequests {
+		if strings.EqualFold(existing.Repo, pr.Repo) && existing.Number == pr.Number {
+			d.PullRequests[i] = pr
+			return nil
+		}
+	}
+	d.PullRequests = append(d.PullRequests, pr)
+	return nil
+}
+
+// RemovePullRequest unlinks the pull request and reports whether it was
+// linked.
+func (d *GitData) RemovePullRequest(repo string, number int) bool {
+	n := len(d.PullRequests)
+	d.PullRequests = slices.DeleteFunc(d.PullRequests, func(pr GitPullRequest) bool {
+		return strings.EqualFold(pr.Repo, repo) && pr.Number == number
+	})
+	return len(d.PullRequests) != n
+}
+
+// validateBranchName applies the parts of git check-ref-format that matter
+// for names people type in.
+func validateBranchName(name string) error {
+	if name == "" ||
+		strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+		strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") ||
+		strings.ContainsAny(name, " ~^:?*[\\\t\n") {
+		return fmt.Errorf("branch name %q is not a valid git ref", name)
+	}
+	return nil
+}
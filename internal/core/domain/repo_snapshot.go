@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RepoSnapshot is what the agent gets to see of a repo on a branch: the
+// tracked paths, and the contents of the files the task mentions.
+type RepoSnapshot struct {
+	Repo   string // owner/name
+	Branch string
+	Paths  []string
+	// Truncated is set when Paths leaves out files because the repo has too
+	// many to list.
+	Truncated bool
+	Files     []RepoFile
+}
+
+type RepoFile struct {
+	Path    string
+	Content string
+}
+
+// pathMentionPattern matches file names with an extension, optionally with
+// their directories, such as "main.go" or "internal/core/domain/gitdata.go".
+var pathMentionPattern = regexp.MustCompile(`(?:[\w.-]+/)*[\w-][\w.-]*\.[A-Za-z0-9]+`)
+
+// PathMentions returns the file paths and names mentioned in text, such as a
+// task description, in order and without repeats.
+func PathMentions(text string) []string {
+	var mentions []string
+	for _, mention := range pathMentionPattern.FindAllString(text, -1) {
+		mention = strings.TrimPrefix(mention, "./")
+		if !slices.Contains(mentions, mention) {
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+// MatchPaths returns the tracked paths that mentions refer to, in the order
+// of mentions. A mention with a directory has to match a path exactly; a bare
+// file name matches every file of that name.
+func MatchPaths(tracked, mentions []string) []string {
+	var matched []string
+	for _, mention := range mentions {
+		for _, p := range tracked {
+			if p == mention || !strings.Contains(mention, "/") && path.Base(p) == mention {
+				if !slices.Contains(matched, p) {
+					matched = append(matched, p)
+				}
+			}
+		}
+	}
+	return matched
+}
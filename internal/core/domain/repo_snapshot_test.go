@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPathMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Fix the redirect in internal/auth/login.go", []string{"internal/auth/login.go"}},
+		{"Update main.go and ./docs/setup.md, then main.go again.", []string{"main.go", "docs/setup.md"}},
+		{"Rename `config.yaml` (see README.md)", []string{"config.yaml", "README.md"}},
+		{"Make login faster", nil},
+	}
+	for _, tt := range tests {
+		if got := PathMentions(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("PathMentions(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	tracked := []string{"README.md", "main.go", "cmd/tool/main.go", "internal/auth/login.go"}
+	tests := []struct {
+		name     string
+		mentions []string
+		want     []string
+	}{
+		{"exact path", []string{"internal/auth/login.go"}, []string{"internal/auth/login.go"}},
+		{"file name matches every directory", []string{"main.go"}, []string{"main.go", "cmd/tool/main.go"}},
+		{"path must match exactly", []string{"auth/login.go"}, nil},
+		{"in order of mentions without repeats", []string{"README.md", "cmd/tool/main.go", "main.go"}, []string{"README.md", "cmd/tool/main.go", "main.go"}},
+		{"unknown", []string{"missing.go"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchPaths(tracked, tt.mentions); !slices.Equal(got, tt.want) {
+				t.Errorf("MatchPaths = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeEntry is a finished work log with the task and feature it was logged
+// against.
+type TimeEntry struct {
+	ID          string
+	TaskID      string
+	TaskName    string
+	FeatureID   string
+	FeatureName string
+	UserID      string // Empty once the user is deleted
+	UserName    string
+	StartedAt   time.Time
+	EndedAt     time.Time
+	Note        string
+}
+
+func (e TimeEntry) Duration() time.Duration {
+	return e.EndedAt.Sub(e.StartedAt)
+}
+
+// TimeTotal is the time logged against one task or feature, or by one user.
+type TimeTotal struct {
+	ID       string
+	Name     string
+	Duration time.Duration
+}
+
+// TimeReport is the time logged between From and To, as entries and as totals
+// per task, feature and user. Totals are sorted by name.
+type TimeReport struct {
+	From      time.Time
+	To        time.Time
+	Total     time.Duration
+	Entries   []TimeEntry
+	ByTask    []TimeTotal
+	ByFeature []TimeTotal
+	ByUser    []TimeTotal
+}
+
+// NewTimeReport totals the entries, which must all have started between from
+// and to.
+func NewTimeReport(from, to time.Time, entries []TimeEntry) TimeReport {
+	report := TimeReport{From: from, To: to, Entries: entries}
+	var byTask, byFeature, byUser totals
+	for _, entry := range entries {
+		d := entry.Duration()
+		report.Total += d
+		byTask.add(entry.TaskID, entry.TaskID, entry.TaskName, d)
+		byFeature.add(entry.FeatureID, entry.FeatureID, entry.FeatureName, d)
+		// Deleted users have no ID left, so they are told apart by name.
+		byUser.add(entry.UserID+"/"+entry.UserName, entry.UserID, entry.UserName, d)
+	}
+	report.ByTask = byTask.sorted()
+	report.ByFeature = byFeature.sorted()
+	report.ByUser = byUser.sorted()
+	return report
+}
+
+// WriteCSV writes one line per entry, for invoicing, with the duration in
+// minutes and decimal hours. Names and notes are escaped with csvText.
+func (r TimeReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "user", "feature", "task", "started_at", "ended_at", "minutes", "hours", "note", "task_id", "work_log_id"})
+	for _, e := range r.Entries {
+		cw.Write([]string{
+			e.StartedAt.Format(time.DateOnly),
+			csvText(e.UserName),
+			csvText(e.FeatureName),
+			csvText(e.TaskName),
+			e.StartedAt.Format(time.RFC3339),
+			e.EndedAt.Format(time.RFC3339),
+			strconv.FormatInt(Minutes(e.Duration()), 10),
+			strconv.FormatFloat(e.Duration().Hours(), 'f', 2, 64),
+			csvText(e.Note),
+			e.TaskID,
+			e.ID,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText keeps spreadsheets from running user text as a formula, such as a
+// note that reads =HYPERLINK(...), by prefixing it with a quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// Minutes rounds d to whole minutes.
+func Minutes(d time.Duration) int64 {
+	return int64(d.Round(time.Minute) / time.Minute)
+}
+
+type totals struct {
+	order []string
+	byKey map[string]*TimeTotal
+}
+
+func (t *totals) add(key, id, name string, d time.Duration) {
+	if t.byKey == nil {
+		t.byKey = make(map[string]*TimeTotal)
+	}
+	total, ok := t.byKey[key]
+	if !ok {
+		total = &TimeTotal{ID: id, Name: name}
+		t.byKey[key] = total
+		t.order = append(t.order, key)
+	}
+	total.Duration += d
+}
+
+func (t *totals) sorted() []TimeTotal {
+	result := make([]TimeTotal, 0, len(t.order))
+	for _, key := range t.order {
+		result = append(result, *t.byKey[key])
+	}
+	slices.SortStableFunc(result, func(a, b TimeTotal) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return result
+}
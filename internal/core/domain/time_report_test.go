@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeReportWriteCSV(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	entry := TimeEntry{
+		ID:          "log-1",
+		TaskID:      "task-1",
+		TaskName:    "Fix login",
+		FeatureName: "Authentication",
+		UserName:    "Ada",
+		StartedAt:   start,
+		EndedAt:     start.Add(90 * time.Minute),
+		Note:        "Pairing",
+	}
+	report := NewTimeReport(start, start.Add(24*time.Hour), []TimeEntry{entry})
+
+	var b strings.Builder
+	if err := report.WriteCSV(&b); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("the output isn't valid CSV: %v", err)
+	}
+	want := []string{"2026-10-18", "Ada", "Authentication", "Fix login", "2026-10-18T09:00:00Z", "2026-10-18T10:30:00Z", "90", "1.50", "Pairing", "task-1", "log-1"}
+	if len(rows) != 2 || strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("rows = %q, want the header and %q", rows, want)
+	}
+}
+
+func TestTimeReportWriteCSVEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`=HYPERLINK("https://evil.example","Click")`, `'=HYPERLINK("https://evil.example","Click")`},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"Fix = sign", "Fix = sign"},
+		{"", ""},
+	}
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		entry := TimeEntry{
+			TaskName:    tt.text,
+			FeatureName: tt.text,
+			UserName:    tt.text,
+			Note:        tt.text,
+			StartedAt:   start,
+			EndedAt:     start.Add(time.Hour),
+		}
+		var b strings.Builder
+		if err := NewTimeReport(start, start.Add(time.Hour), []TimeEntry{entry}).WriteCSV(&b); err != nil {
+			t.Fatalf("WriteCSV: %v", err)
+		}
+		rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("the output isn't valid CSV: %v", err)
+		}
+		row := rows[1]
+		for _, i := range []int{1, 2, 3, 8} { // user, feature, task, note
+			if row[i] != tt.want {
+				t.Errorf("%s of %q = %q, want %q", rows[0][i], tt.text, row[i], tt.want)
+			}
+		}
+	}
+}
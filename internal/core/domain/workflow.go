@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Workflow describes the statuses and priorities an entity may have and which
+// status changes are allowed.
+type Workflow struct {
+	InitialStatus string              `json:"initial_status"`
+	Statuses      []string            `json:"statuses"`
+	Transitions   map[string][]string `json:"transitions"`
+	Priorities    []string            `json:"priorities"`
+}
+
+// Task statuses with a meaning beyond the workflow. Tasks are started in
+// StatusInProgress, and are closed in StatusDone or StatusCancelled: closed
+// tasks no longer block others, aren't overdue and count as finished in
+// progress roll ups. Git activity moves tasks to StatusInProgress,
+// StatusReview and StatusDone when the workflow allows it.
+const (
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+// RequiredTaskStatuses must be in every task workflow. StatusReview is left
+// out, since moving tasks to review on a pull request is skipped without it.
+var RequiredTaskStatuses = []string{StatusInProgress, StatusDone, StatusCancelled}
+
+// WorkflowConfig holds the workflows for tasks and features.
+type WorkflowConfig struct {
+	Task    Workflow `json:"task"`
+	Feature Workflow `json:"feature"`
+	// DeriveFeatureStatus moves a feature to done once all of its tasks are
+	// done, leaving cancelled tasks aside.
+	DeriveFeatureStatus bool `json:"derive_feature_status"`
+}
+
+// DefaultWorkflow is todo -> in_progress -> review -> done, with the option to
+// cancel work that hasn't been reviewed yet and to reopen finished work.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		InitialStatus: "todo",
+		Statuses:      []string{"todo", "in_progress", "review", "done", "cancelled"},
+		Transitions: map[string][]string{
+			"todo":        {"in_progress", "cancelled"},
+			"in_progress": {"todo", "review", "cancelled"},
+			"review":      {"in_progress", "done"},
+			"done":        {"in_progress"},
+			"cancelled":   {"todo"},
+		},
+		Priorities: []string{"low", "medium", "high", "urgent"},
+	}
+}
+
+func DefaultWorkflowConfig() WorkflowConfig {
+	return WorkflowConfig{
+		Task:    DefaultWorkflow(),
+		Feature: DefaultWorkflow(),
+	}
+}
+
+func (w Workflow) HasStatus(status string) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+func (w Workflow) HasPriority(priority string) bool {
+	return slices.Contains(w.Priorities, priority)
+}
+
+// CanTransition reports whether an entity may move from one status to
+// another. Staying in the same status is always allowed, and so is leaving a
+// status the workflow doesn't know about, so rows written before the workflow
+// was introduced can be fixed up.
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to || !w.HasStatus(from) {
+		return true
+	}
+	return slices.Contains(w.Transitions[from], to)
+}
+
+// NextStatuses returns the statuses reachable from the given one.
+func (w Workflow) NextStatuses(from string) []string {
+	if !w.HasStatus(from) {
+		return w.Statuses
+	}
+	return w.Transitions[from]
+}
+
+// Validate checks that the workflow is internally consistent.
+func (w Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("workflow has no statuses")
+	}
+	if !w.HasStatus(w.InitialStatus) {
+		return fmt.Errorf("initial status %q is not a known status", w.InitialStatus)
+	}
+	for from, targets := range w.Transitions {
+		if !w.HasStatus(from) {
+			return fmt.Errorf("transition from unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !w.HasStatus(to) {
+				return fmt.Errorf("transition from %q to unknown status %q", from, to)
+			}
+		}
+	}
+	if len(w.Priorities) == 0 {
+		return fmt.Errorf("workflow has no priorities")
+	}
+	return nil
+}
+
+func (c WorkflowConfig) Validate() error {
+	if err := c.Task.Validate(); err != nil {
+		return fmt.Errorf("task workflow: %w", err)
+	}
+	for _, status := range RequiredTaskStatuses {
+		if !c.Task.HasStatus(status) {
+			return fmt.Errorf("task workflow: status %q is required", status)
+		}
+	}
+	if err := c.Feature.Validate(); err != nil {
+		return fmt.Errorf("feature workflow: %w", err)
+	}
+	if c.DeriveFeatureStatus && !c.Feature.HasStatus(StatusDone) {
+		return fmt.Errorf("derive_feature_status needs a done status in the feature workflow")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+// without returns the default workflow without the status, and without the
+// transitions to and from it.
+func without(status string) Workflow {
+	w := DefaultWorkflow()
+	w.Statuses = slices.DeleteFunc(slices.Clone(w.Statuses), func(s string) bool { return s == status })
+	transitions := make(map[string][]string)
+	for from, targets := range w.Transitions {
+		if from != status {
+			transitions[from] = slices.DeleteFunc(slices.Clone(targets), func(s string) bool { return s == status })
+		}
+	}
+	w.Transitions = transitions
+	return w
+}
+
+func TestWorkflowConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  WorkflowConfig
+		wantErr bool
+	}{
+		{"default", DefaultWorkflowConfig(), false},
+		{"task workflow without review", WorkflowConfig{Task: without(StatusReview), Feature: DefaultWorkflow()}, false},
+		{"task workflow without in_progress", WorkflowConfig{Task: without(StatusInProgress), Feature: DefaultWorkflow()}, true},
+		{"task workflow without done", WorkflowConfig{Task: without(StatusDone), Feature: DefaultWorkflow()}, true},
+		{"task workflow without cancelled", WorkflowConfig{Task: without(StatusCancelled), Feature: DefaultWorkflow()}, true},
+		{"feature workflow without done", WorkflowConfig{Task: DefaultWorkflow(), Feature: without(StatusDone)}, false},
+		{"derived feature status without done", WorkflowConfig{Task: DefaultWorkflow(), Feature: without(StatusDone), DeriveFeatureStatus: true}, true},
+		{"derived feature status", WorkflowConfig{Task: DefaultWorkflow(), Feature: DefaultWorkflow(), DeriveFeatureStatus: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Validate accepted the config")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate = %v, want nil", err)
+			}
+		})
+	}
+}
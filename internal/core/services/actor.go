@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type userKey struct{}
+
+// ContextWithUser returns a copy of ctx that carries the authenticated user
+// performing the request. Services record them as the actor in the audit log
+// and as the creator of new rows.
+func ContextWithUser(ctx context.Context, user db.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, if the request has one.
+func UserFromContext(ctx context.Context) (db.User, bool) {
+	user, ok := ctx.Value(userKey{}).(db.User)
+	return user, ok
+}
+
+// actorFromContext returns the acting user's ID, or an invalid UUID when the
+// request is anonymous, as for background workers.
+func actorFromContext(ctx context.Context) pgt.UUID {
+	user, _ := UserFromContext(ctx)
+	return user.ID
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+// agentSystemPrompt tells the model what the agent expects back. The diff
+// block is what gets applied to the task branch.
+const agentSystemPrompt = `You are a software engineer working on a task from a project tracker.
+Read the task, its feature and the repository files shown with it, then implement the task.
+Reply with a short summary of what you changed and why, followed by all code changes
+as a single unified diff (as produced by "git diff") in one fenced block starting with ` + "```diff" + `.
+Paths in the diff are relative to the repository root. The diff has to apply exactly,
+so only change files whose contents are shown, or add new files. If the task can't be
+done without more information or other files, explain what is missing, naming the files
+you need, and leave out the diff.`
+
+// Agent run states. A run is queued until a worker claims it, and is queued
+// again between retries.
+const (
+	AgentRunQueued    = "queued"
+	AgentRunRunning   = "running"
+	AgentRunSucceeded = "succeeded"
+	AgentRunFailed    = "failed"
+	AgentRunCancelled = "cancelled"
+)
+
+// AgentConfig controls how agent runs are executed.
+type AgentConfig struct {
+	Workers      int           // number of runs executed at the same time
+	MaxAttempts  int           // attempts per run before it fails
+	RetryBackoff time.Duration // delay before the first retry, doubled for each further one
+	MaxBackoff   time.Duration
+	PollInterval time.Duration // how often idle workers look for new runs
+	Lease        time.Duration // a run whose worker stops renewing the lease is picked up again
+}
+
+func DefaultAgentConfig() AgentConfig {
+	return AgentConfig{
+		Workers:      2,
+		MaxAttempts:  3,
+		RetryBackoff: 30 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		PollInterval: 2 * time.Second,
+		Lease:        time.Minute,
+	}
+}
+
+// AgentService queues agent runs and executes them on background workers, so
+// a slow LLM never holds up an HTTP request. When the LLM answers with a diff
+// it is committed to the task branch through the RepoWorkspace and a pull
+// request is opened for the branch.
+type AgentService struct {
+	queries      *db.Queries
+	tasks        *TaskService
+	pullRequests *PullRequestService
+	comments     *CommentService
+	llm          ports.LLMProvider
+	workspace    ports.RepoWorkspace
+	config       AgentConfig
+}
+
+func NewAgentService(queries *db.Queries, tasks *TaskService, pullRequests *PullRequestService, comments *CommentService, llm ports.LLMProvider, workspace ports.RepoWorkspace, config AgentConfig) *AgentService {
+	return &AgentService{
+		queries:      queries,
+		tasks:        tasks,
+		pullRequests: pullRequests,
+		comments:     comments,
+		llm:          llm,
+		workspace:    workspace,
+		config:       config,
+	}
+}
+
+// StartRun queues a run of the coding agent on the task.
+func (s *AgentService) StartRun(ctx context.Context, taskID pgt.UUID) (db.AgentRun, error) {
+	if err := requireMember(ctx, "running the agent"); err != nil {
+		return db.AgentRun{}, err
+	}
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return db.AgentRun{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	run, err := s.queries.CreateAgentRun(ctx, db.CreateAgentRunParams{
+		TaskID:      taskID,
+		MaxAttempts: int32(s.config.MaxAttempts),
+		CreatedBy:   actorFromContext(ctx),
+	})
+	if err != nil {
+		return db.AgentRun{}, fmt.Errorf("failed to create agent run: %w", err)
+	}
+	return run, nil
+}
+
+func (s *AgentService) GetRun(ctx context.Context, id pgt.UUID) (db.AgentRun, error) {
+	run, err := s.queries.GetAgentRun(ctx, id)
+	if err != nil {
+		return db.AgentRun{}, fmt.Errorf("failed to get agent run: %w", notFound(err))
+	}
+	return run, nil
+}
+
+// ListRuns returns the task's runs, newest first.
+func (s *AgentService) ListRuns(ctx context.Context, taskID pgt.UUID) ([]db.AgentRun, error) {
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	runs, err := s.queries.ListAgentRunsByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent runs: %w", err)
+	}
+	return runs, nil
+}
+
+// CancelRun cancels a queued or running run. A running run is stopped the
+// next time its worker renews the lease; whatever it produces is discarded.
+func (s *AgentService) CancelRun(ctx context.Context, id pgt.UUID) (db.AgentRun, error) {
+	if err := requireMember(ctx, "cancelling agent runs"); err != nil {
+		return db.AgentRun{}, err
+	}
+	run, err := s.queries.CancelAgentRun(ctx, id)
+	if err == nil {
+		return run, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return db.AgentRun{}, fmt.Errorf("failed to cancel agent run: %w", err)
+	}
+	current, err := s.queries.GetAgentRun(ctx, id)
+	if err != nil {
+		return db.AgentRun{}, fmt.Errorf("failed to get agent run: %w", notFound(err))
+	}
+	return db.AgentRun{}, fmt.Errorf("%w: agent run already %s", ErrConflict, current.Status)
+}
+
+// RunWorkers executes queued runs on config.Workers goroutines until ctx is
+// cancelled.
+func (s *AgentService) RunWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runWorker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *AgentService) runWorker(ctx context.Context) {
+	for {
+		claimed, err := s.processNext(ctx)
+		if err != nil {
+			log.Printf("AgentService: %v", err)
+		}
+		if claimed {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// processNext claims one due run and executes it. It reports whether there
+// was a run to claim.
+func (s *AgentService) processNext(ctx context.Context) (bool, error) {
+	run, err := s.queries.ClaimAgentRun(ctx, s.leaseDeadline())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim agent run: %w", err)
+	}
+
+	if run.Attempts > run.MaxAttempts {
+		// Only happens when workers keep dying while running it.
+		return true, s.fail(ctx, run, "no attempts left after the worker running it stopped")
+	}
+	return true, s.execute(ctx, run)
+}
+
+func (s *AgentService) execute(ctx context.Context, run db.AgentRun) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.keepLease(runCtx, cancel, run.ID)
+
+	s.logRun(ctx, run.ID, "attempt %d of %d started", run.Attempts, run.MaxAttempts)
+
+	task, err := s.queries.GetTask(runCtx, run.TaskID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.fail(ctx, run, "task was deleted")
+	}
+	if err != nil {
+		return s.retry(ctx, run, fmt.Errorf("failed to get task: %w", err))
+	}
+	feature, err := s.queries.GetFeature(runCtx, task.FeatureID)
+	if err != nil {
+		return s.retry(ctx, run, fmt.Errorf("failed to get feature: %w", err))
+	}
+	gitData, err := agentGitData(task, feature)
+	if err != nil {
+		return s.fail(ctx, run, err.Error())
+	}
+	var snapshot domain.RepoSnapshot
+	if len(gitData.Repos) > 0 {
+		// The diff is committed to the first repo, so that is the one shown.
+		repo := gitData.Repos[0]
+		branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+		mentions := domain.PathMentions(task.Name + "\n" + task.Description.String)
+		snapshot, err = s.workspace.ReadRepo(runCtx, repo, branch, mentions)
+		if err != nil {
+			return s.retry(ctx, run, fmt.Errorf("failed to read %s: %w", repo.FullName(), err))
+		}
+		s.logRun(ctx, run.ID, "read %d of the %d files mentioned by the task from %s", len(snapshot.Files), len(mentions), repo.FullName())
+	}
+	req := buildAgentPrompt(task, feature, gitData, snapshot)
+
+	s.logRun(ctx, run.ID, "sending %d character prompt to %s", len(req.Prompt), s.llm.Name())
+	resp, err := s.llm.Generate(runCtx, req)
+	if runCtx.Err() != nil && ctx.Err() == nil {
+		s.logRun(ctx, run.ID, "stopped, the run was cancelled")
+		return nil
+	}
+	if err != nil {
+		return s.retry(ctx, run, fmt.Errorf("%s failed: %w", s.llm.Name(), err))
+	}
+	s.logRun(ctx, run.ID, "received %d characters from %s", len(resp.Text), resp.Model)
+
+	if err := s.commitChanges(runCtx, run, task, gitData, resp.Text); err != nil {
+		if runCtx.Err() != nil && ctx.Err() == nil {
+			s.logRun(ctx, run.ID, "stopped, the run was cancelled")
+			return nil
+		}
+		return s.retry(ctx, run, err)
+	}
+
+	n, err := s.queries.CompleteAgentRun(ctx, db.CompleteAgentRunParams{
+		ID:       run.ID,
+		Provider: pgt.Text{String: s.llm.Name(), Valid: true},
+		Model:    pgt.Text{String: resp.Model, Valid: true},
+		Prompt:   pgt.Text{String: req.Prompt, Valid: true},
+		Output:   pgt.Text{String: resp.Text, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete agent run: %w", err)
+	}
+	if n == 0 {
+		s.logRun(ctx, run.ID, "output discarded, the run was cancelled")
+		return nil
+	}
+
+	// The summary is posted on the task's discussion once the run is done.
+	// It is best effort, the output is kept on the run either way.
+	summary, _ := splitDiff(resp.Text)
+	if _, err := s.comments.PostAgentSummary(ctx, run, agentSummary(summary, run)); err != nil {
+		s.logRun(ctx, run.ID, "%v", err)
+	}
+	return nil
+}
+
+// keepLease renews the run's lease until ctx is done, and cancels the run when
+// the lease can't be renewed because the run is no longer running.
+func (s *AgentService) keepLease(ctx context.Context, cancel context.CancelFunc, id pgt.UUID) {
+	ticker := time.NewTicker(s.config.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := s.queries.ExtendAgentRunLease(ctx, db.ExtendAgentRunLeaseParams{ID: id, LockedUntil: s.leaseDeadline()})
+		if err != nil {
+			log.Printf("AgentService: Failed to extend lease of run %s: %v", uuid.UUID(id.Bytes), err)
+			continue
+		}
+		if n == 0 {
+			cancel()
+			return
+		}
+	}
+}
+
+// retry queues the run again with exponential backoff, or fails it when it
+// has no attempts left.
+func (s *AgentService) retry(ctx context.Context, run db.AgentRun, cause error) error {
+	if run.Attempts >= run.MaxAttempts {
+		return s.fail(ctx, run, cause.Error())
+	}
+	delay := s.config.RetryBackoff << (run.Attempts - 1)
+	if delay > s.config.MaxBackoff || delay <= 0 {
+		delay = s.config.MaxBackoff
+	}
+	s.logRun(ctx, run.ID, "attempt %d failed: %v; retrying in %s", run.Attempts, cause, delay)
+	_, err := s.queries.RetryAgentRun(ctx, db.RetryAgentRunParams{
+		ID:       run.ID,
+		Error:    pgt.Text{String: cause.Error(), Valid: true},
+		RunAfter: pgt.Timestamptz{Time: time.Now().Add(delay), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue agent run: %w", err)
+	}
+	return nil
+}
+
+func (s *AgentService) fail(ctx context.Context, run db.AgentRun, reason string) error {
+	s.logRun(ctx, run.ID, "failed: %s", reason)
+	_, err := s.queries.FailAgentRun(ctx, db.FailAgentRunParams{
+		ID:    run.ID,
+		Error: pgt.Text{String: reason, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark agent run failed: %w", err)
+	}
+	return nil
+}
+
+// logRun appends a timestamped line to the run's logs. Logging is best
+// effort and never fails the run.
+func (s *AgentService) logRun(ctx context.Context, id pgt.UUID, format string, args ...any) {
+	line := time.Now().UTC().Format(time.RFC3339) + " " + fmt.Sprintf(format, args...) + "\n"
+	if err := s.queries.AppendAgentRunLog(ctx, db.AppendAgentRunLogParams{ID: id, Line: line}); err != nil {
+		log.Printf("AgentService: Failed to write log of run %s: %v", uuid.UUID(id.Bytes), err)
+	}
+}
+
+func (s *AgentService) leaseDeadline() pgt.Timestamptz {
+	return pgt.Timestamptz{Time: time.Now().Add(s.config.Lease), Valid: true}
+}
+
+// commitChanges applies the diff in the LLM output to the task branch of the
+// first repo the task works in, records the branch and commit in the task's
+// git_data and opens a pull request. Outputs without a diff, and tasks
+// without a repo, are left alone.
+func (s *AgentService) commitChanges(ctx context.Context, run db.AgentRun, task db.Task, gitData domain.GitData, output string) error {
+	_, diff := splitDiff(output)
+	if diff == "" {
+		s.logRun(ctx, run.ID, "output has no diff, nothing to commit")
+		return nil
+	}
+	if len(gitData.Repos) == 0 {
+		s.logRun(ctx, run.ID, "task has no linked repo, not committing the diff")
+		return nil
+	}
+
+	repo := gitData.Repos[0]
+	branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+	message := fmt.Sprintf("%s\n\nTask: %s\nAgent run: %s\n", task.Name, uuid.UUID(task.ID.Bytes), uuid.UUID(run.ID.Bytes))
+	sha, err := s.workspace.CommitPatch(ctx, repo, branch, diff, message)
+	if err != nil {
+		return fmt.Errorf("failed to commit to %s: %w", repo.FullName(), err)
+	}
+	s.logRun(ctx, run.ID, "committed %s to %s on %s", sha, repo.FullName(), branch)
+
+	// The commit is pushed at this point, so failing to record it or to open
+	// the pull request doesn't retry the run, which would ask the LLM again;
+	// the commit and pull request can be linked by hand.
+	if updated, err := s.tasks.AddTaskCommit(ctx, task.ID, domain.GitCommit{Repo: repo.FullName(), SHA: sha, Branch: branch, Message: task.Name}); err != nil {
+		s.logRun(ctx, run.ID, "failed to record commit %s: %v", sha, err)
+	} else {
+		task = updated
+	}
+	pr, err := s.pullRequests.OpenPullRequest(ctx, task, repo, branch)
+	if err != nil {
+		s.logRun(ctx, run.ID, "%v", err)
+		return nil
+	}
+	s.logRun(ctx, run.ID, "pull request %s#%d is %s: %s", pr.Repo, pr.Number, pr.State, pr.URL)
+	return nil
+}
+
+// splitDiff separates the first ```diff (or ```patch) block of the output
+// from the text around it. An output without a complete block is all text.
+func splitDiff(output string) (text, diff string) {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		fence := strings.TrimSpace(line)
+		if fence != "```diff" && fence != "```patch" {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == "```" {
+				text = strings.Join(slices.Concat(lines[:i], lines[j+1:]), "\n")
+				return text, strings.Join(lines[i+1:j], "\n") + "\n"
+			}
+		}
+		return output, ""
+	}
+	return output, ""
+}
+
+// agentSummary is the comment posted for a completed run: the LLM's
+// explanation without the diff, which is in the commit.
+func agentSummary(text string, run db.AgentRun) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		text = "The agent finished without a summary."
+	}
+	return fmt.Sprintf("%s\n\n_Agent run %s_", text, uuid.UUID(run.ID.Bytes))
+}
+
+// agentGitData returns the task's git data with the feature's repos added,
+// since tasks often don't repeat them.
+func agentGitData(task db.Task, feature db.Feature) (domain.GitData, error) {
+	gitData := domain.ParseStoredGitData(task.GitData)
+	featureRepos, err := domain.ParseRepos(feature.Repos)
+	if err != nil {
+		return domain.GitData{}, fmt.Errorf("%w: feature has %v", ErrValidation, err)
+	}
+	for _, repo := range featureRepos {
+		gitData.LinkRepo(repo)
+	}
+	return gitData, nil
+}
+
+// buildAgentPrompt describes the task, its feature and everything linked in
+// git_data, followed by the files of the snapshot, if any.
+func buildAgentPrompt(task db.Task, feature db.Feature, gitData domain.GitData, snapshot domain.RepoSnapshot) ports.LLMRequest {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Task: %s\n", task.Name)
+	fmt.Fprintf(&b, "ID: %s\n", uuid.UUID(task.ID.Bytes))
+	if task.Status.Valid {
+		fmt.Fprintf(&b, "Status: %s\n", task.Status.String)
+	}
+	if task.Priority.Valid {
+		fmt.Fprintf(&b, "Priority: %s\n", task.Priority.String)
+	}
+	if task.Description.Valid && task.Description.String != "" {
+		fmt.Fprintf(&b, "\n%s\n", task.Description.String)
+	}
+
+	fmt.Fprintf(&b, "\n# Feature: %s\n", feature.Name)
+	if feature.Description.Valid && feature.Description.String != "" {
+		fmt.Fprintf(&b, "\n%s\n", feature.Description.String)
+	}
+
+	if len(gitData.Repos) > 0 {
+		b.WriteString("\n# Repositories\n")
+		for _, repo := range gitData.Repos {
+			fmt.Fprintf(&b, "- %s", repo.FullName())
+			if repo.URL != "" {
+				fmt.Fprintf(&b, " (%s)", repo.URL)
+			}
+			if repo.DefaultBranch != "" {
+				fmt.Fprintf(&b, ", default branch %s", repo.DefaultBranch)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(gitData.Branches) > 0 {
+		b.WriteString("\n# Branches\n")
+		for _, branch := range gitData.Branches {
+			fmt.Fprintf(&b, "- %s: %s\n", branch.Repo, branch.Name)
+		}
+	}
+	if len(gitData.Commits) > 0 {
+		b.WriteString("\n# Commits so far\n")
+		for _, commit := range gitData.Commits {
+			fmt.Fprintf(&b, "- %s@%s %s\n", commit.Repo, commit.SHA, commit.Message)
+		}
+	}
+	if len(gitData.PullRequests) > 0 {
+		b.WriteString("\n# Pull requests\n")
+		for _, pr := range gitData.PullRequests {
+			fmt.Fprintf(&b, "- %s#%d (%s)", pr.Repo, pr.Number, pr.State)
+			if pr.URL != "" {
+				fmt.Fprintf(&b, " %s", pr.URL)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if snapshot.Repo != "" {
+		fmt.Fprintf(&b, "\n# Files in %s on %s\n", snapshot.Repo, snapshot.Branch)
+		for _, path := range snapshot.Paths {
+			fmt.Fprintf(&b, "- %s\n", path)
+		}
+		if snapshot.Truncated {
+			b.WriteString("- (more files not listed)\n")
+		}
+		for _, file := range snapshot.Files {
+			fmt.Fprintf(&b, "\n# File: %s\n```\n%s", file.Path, file.Content)
+			if !strings.HasSuffix(file.Content, "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("```\n")
+		}
+	}
+
+	return ports.LLMRequest{SystemPrompt: agentSystemPrompt, Prompt: b.String()}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/adapters/llm"
+	"shelke.dev/api/internal/core/domain"
+)
+
+func TestSplitDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		wantText string
+		wantDiff string
+	}{
+		{
+			name:     "no fence",
+			output:   "Nothing to change.",
+			wantText: "Nothing to change.",
+		},
+		{
+			name:     "diff fence",
+			output:   "Fixed it.\n```diff\n-a\n+b\n```\nDone.",
+			wantText: "Fixed it.\nDone.",
+			wantDiff: "-a\n+b\n",
+		},
+		{
+			name:     "patch fence",
+			output:   "```patch\n-a\n+b\n```",
+			wantText: "",
+			wantDiff: "-a\n+b\n",
+		},
+		{
+			name:     "indented fences",
+			output:   "Fixed it.\n  ```diff\n-a\n+b\n  ```",
+			wantText: "Fixed it.",
+			wantDiff: "-a\n+b\n",
+		},
+		{
+			name:     "unclosed fence",
+			output:   "Fixed it.\n```diff\n-a\n+b\n",
+			wantText: "Fixed it.\n```diff\n-a\n+b\n",
+		},
+		{
+			name:     "other language",
+			output:   "```go\nfunc main() {}\n```",
+			wantText: "```go\nfunc main() {}\n```",
+		},
+		{
+			name:     "first block only",
+			output:   "```diff\n-a\n```\n```diff\n-b\n```",
+			wantText: "```diff\n-b\n```",
+			wantDiff: "-a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, diff := splitDiff(tt.output)
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if diff != tt.wantDiff {
+				t.Errorf("diff = %q, want %q", diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestBuildAgentPrompt(t *testing.T) {
+	taskID := uuid.MustParse("3fa85f64-5717-4562-b3fc-2c963f66afa6")
+	task := db.Task{
+		ID:          pgt.UUID{Bytes: taskID, Valid: true},
+		Name:        "Fix login",
+		Description: pgt.Text{String: "Users can't log in with SSO.", Valid: true},
+		Status:      pgt.Text{String: "todo", Valid: true},
+		Priority:    pgt.Text{String: "high", Valid: true},
+	}
+	feature := db.Feature{
+		Name:        "Authentication",
+		Description: pgt.Text{String: "Everything about signing in.", Valid: true},
+	}
+
+	tests := []struct {
+		name     string
+		gitData  domain.GitData
+		snapshot domain.RepoSnapshot
+		want     []string
+		notWant  []string
+	}{
+		{
+			name: "without git data",
+			want: []string{
+				"# Task: Fix login\n",
+				"ID: " + taskID.String() + "\n",
+				"Status: todo\n",
+				"Priority: high\n",
+				"\nUsers can't log in with SSO.\n",
+				"# Feature: Authentication\n",
+				"\nEverything about signing in.\n",
+			},
+			notWant: []string{"# Repositories", "# Branches", "# Commits so far", "# Pull requests", "# Files in", "# File:"},
+		},
+		{
+			name: "with git data",
+			gitData: domain.GitData{
+				Repos:        []domain.GitRepo{{Owner: "acme", Name: "api", URL: "https://git.example.com/acme/api.git", DefaultBranch: "main"}},
+				Branches:     []domain.GitBranch{{Repo: "acme/api", Name: "task/1"}},
+				Commits:      []domain.GitCommit{{Repo: "acme/api", SHA: "0123abc", Message: "Fix login"}},
+				PullRequests: []domain.GitPullRequest{{Repo: "acme/api", Number: 7, State: "open", URL: "https://github.com/acme/api/pull/7"}},
+			},
+			want: []string{
+				"# Repositories\n- acme/api (https://git.example.com/acme/api.git), default branch main\n",
+				"# Branches\n- acme/api: task/1\n",
+				"# Commits so far\n- acme/api@0123abc Fix login\n",
+				"# Pull requests\n- acme/api#7 (open) https://github.com/acme/api/pull/7\n",
+			},
+		},
+		{
+			name: "with repo snapshot",
+			snapshot: domain.RepoSnapshot{
+				Repo:      "acme/api",
+				Branch:    "task/1",
+				Paths:     []string{"README.md", "main.go"},
+				Truncated: true,
+				Files:     []domain.RepoFile{{Path: "main.go", Content: "package main"}},
+			},
+			want: []string{
+				"# Files in acme/api on task/1\n- README.md\n- main.go\n- (more files not listed)\n",
+				"# File: main.go\n```\npackage main\n```\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := buildAgentPrompt(task, feature, tt.gitData, tt.snapshot)
+			if req.SystemPrompt != agentSystemPrompt {
+				t.Errorf("SystemPrompt = %q, want agentSystemPrompt", req.SystemPrompt)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(req.Prompt, want) {
+					t.Errorf("prompt doesn't contain %q:\n%s", want, req.Prompt)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(req.Prompt, notWant) {
+					t.Errorf("prompt contains %q:\n%s", notWant, req.Prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestAgentPromptRoundTripThroughFake(t *testing.T) {
+	task := db.Task{ID: pgt.UUID{Bytes: uuid.New(), Valid: true}, Name: "Fix login"}
+	req := buildAgentPrompt(task, db.Feature{Name: "Authentication"}, domain.GitData{}, domain.RepoSnapshot{})
+	fake := llm.NewFake("Fixed the redirect.\n```diff\n-a\n+b\n```")
+
+	resp, err := fake.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got := fake.Requests(); len(got) != 1 || got[0] != req {
+		t.Fatalf("fake received %+v, want the built prompt", got)
+	}
+	text, diff := splitDiff(resp.Text)
+	if text != "Fixed the redirect." || diff != "-a\n+b\n" {
+		t.Errorf("splitDiff = %q, %q", text, diff)
+	}
+	if summary := agentSummary(text, db.AgentRun{}); !strings.HasPrefix(summary, "Fixed the redirect.\n\n_Agent run ") {
+		t.Errorf("summary = %q", summary)
+	}
+}
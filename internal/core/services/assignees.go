@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// AgentUserID is the user standing for the AI agent, added by the
+// task_assignees migration with RoleAgent. Assigning it to a task queues an
+// agent run, and the agent's comments are posted as this user.
+var AgentUserID = pgt.UUID{Bytes: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Valid: true}
+
+// AssignTask assigns the user to the task. Tasks may have several
+// assignees, and assigning someone twice is a no-op. Assigning AgentUserID
+// hands the task to the agent by queuing a run, like AgentService.StartRun.
+func (s *TaskService) AssignTask(ctx context.Context, id, userID pgt.UUID) (db.Task, error) {
+	if err := requireMember(ctx, "assigning tasks"); err != nil {
+		return db.Task{}, err
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		task, err = q.GetTaskForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		if _, err := q.GetUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to get user: %w", notFound(err))
+		}
+		n, err := q.AddTaskAssignee(ctx, db.AddTaskAssigneeParams{
+			TaskID:     id,
+			UserID:     userID,
+			AssignedBy: actorFromContext(ctx),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to assign task: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		if userID == AgentUserID {
+			_, err := q.CreateAgentRun(ctx, db.CreateAgentRunParams{
+				TaskID:      id,
+				MaxAttempts: int32(s.agent.MaxAttempts),
+				CreatedBy:   actorFromContext(ctx),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create agent run: %w", err)
+			}
+		}
+		return recordAudit(ctx, q, AuditEntityTask, id, AuditActionAssigneeAdded, nil, map[string]any{"assignee": auditUUID(userID)})
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// UnassignTask removes the user from the task's assignees.
+func (s *TaskService) UnassignTask(ctx context.Context, id, userID pgt.UUID) (db.Task, error) {
+	if err := requireMember(ctx, "assigning tasks"); err != nil {
+		return db.Task{}, err
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		task, err = q.GetTaskForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		n, err := q.RemoveTaskAssignee(ctx, db.RemoveTaskAssigneeParams{TaskID: id, UserID: userID})
+		if err != nil {
+			return fmt.Errorf("failed to unassign task: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %s isn't assigned to the task", ErrNotFound, uuid.UUID(userID.Bytes))
+		}
+		return recordAudit(ctx, q, AuditEntityTask, id, AuditActionAssigneeRemoved, map[string]any{"assignee": auditUUID(userID)}, nil)
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// ListAssignedTasks returns the tasks assigned to the user outside the
+// trash, optionally only those with the given status.
+func (s *TaskService) ListAssignedTasks(ctx context.Context, userID pgt.UUID, status pgt.Text) ([]db.Task, error) {
+	if _, err := s.queries.GetUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", notFound(err))
+	}
+	tasks, err := s.queries.ListAssignedTasks(ctx, db.ListAssignedTasksParams{UserID: userID, Status: status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assigned tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// listAssigneesByTask loads the assignees of several tasks with one query and
+// groups them by task ID, sorted by name.
+func listAssigneesByTask(ctx context.Context, q *db.Queries, taskIDs []pgt.UUID) (map[pgt.UUID][]db.User, error) {
+	rows, err := q.ListAssigneesByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task assignees: %w", err)
+	}
+	byTask := make(map[pgt.UUID][]db.User, len(taskIDs))
+	for _, row := range rows {
+		byTask[row.TaskID] = append(byTask[row.TaskID], row.User)
+	}
+	return byTask, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+const (
+	AuditEntityTask    = "task"
+	AuditEntityFeature = "feature"
+
+	AuditActionCreate            = "create"
+	AuditActionUpdate            = "update"
+	AuditActionDelete            = "delete"
+	AuditActionRestore           = "restore"
+	AuditActionOwnerAdded        = "owner_added"
+	AuditActionOwnerRemoved      = "owner_removed"
+	AuditActionDependencyAdded   = "dependency_added"
+	AuditActionDependencyRemoved = "dependency_removed"
+	AuditActionLabelAdded        = "label_added"
+	AuditActionLabelRemoved      = "label_removed"
+	AuditActionAssigneeAdded     = "assignee_added"
+	AuditActionAssigneeRemoved   = "assignee_removed"
+)
+
+// fieldChange is the before/after pair stored per changed field.
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditService struct {
+	queries *db.Queries
+}
+
+func NewAuditService(queries *db.Queries) *AuditService {
+	return &AuditService{queries: queries}
+}
+
+// ListHistory returns the audit events of one entity, newest first. History
+// is kept after the entity is deleted.
+func (s *AuditService) ListHistory(ctx context.Context, entityType string, entityID pgt.UUID) ([]db.AuditEvent, error) {
+	events, err := s.queries.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		EntityType: entityType,
+		EntityID:   entityID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return events, nil
+}
+
+// recordAudit stores the field-level diff between before and after. It must
+// be called with the transaction's queries so the event is committed or rolled
+// back together with the change it describes. Updates that change nothing are
+// not recorded.
+func recordAudit(ctx context.Context, q *db.Queries, entityType string, entityID pgt.UUID, action string, before, after map[string]any) error {
+	changes := diffFields(before, after)
+	if len(changes) == 0 && action == AuditActionUpdate {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	_, err = q.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		ActorID:    actorFromContext(ctx),
+		Changes:    data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+func diffFields(before, after map[string]any) map[string]fieldChange {
+	changes := make(map[string]fieldChange)
+	for field, value := range after {
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = fieldChange{Before: before[field], After: value}
+		}
+	}
+	for field, value := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = fieldChange{Before: value, After: nil}
+		}
+	}
+	return changes
+}
+
+// auditText, auditUUID, auditDate, auditInt and auditJSON turn nullable
+// columns into plain values that compare and marshal cleanly.
+func auditText(t pgt.Text) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.String
+}
+
+func auditUUID(id pgt.UUID) any {
+	if !id.Valid {
+		return nil
+	}
+	return uuid.UUID(id.Bytes).String()
+}
+
+func auditDate(d pgt.Date) any {
+	if !d.Valid {
+		return nil
+	}
+	return d.Time.Format(time.DateOnly)
+}
+
+func auditInt(n pgt.Int4) any {
+	if !n.Valid {
+		return nil
+	}
+	return n.Int32
+}
+
+func auditJSON(data []byte) any {
+	if data == nil {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+	return value
+}
+
+func taskAuditFields(task db.Task) map[string]any {
+	return map[string]any{
+		"name":            task.Name,
+		"description":     auditText(task.Description),
+		"created_by":      auditUUID(task.CreatedBy),
+		"feature_id":      auditUUID(task.FeatureID),
+		"feature_name":    auditText(task.FeatureName),
+		"priority":        auditText(task.Priority),
+		"status":          auditText(task.Status),
+		"git_data":        auditJSON(task.GitData),
+		"parent_task_id":  auditUUID(task.ParentTaskID),
+		"start_date":      auditDate(task.StartDate),
+		"due_date":        auditDate(task.DueDate),
+		"estimate_points": auditInt(task.EstimatePoints),
+	}
+}
+
+func featureAuditFields(feature db.Feature) map[string]any {
+	return map[string]any{
+		"name":        feature.Name,
+		"description": auditText(feature.Description),
+		"created_by":  auditUUID(feature.CreatedBy),
+		"priority":    auditText(feature.Priority),
+		"status":      auditText(feature.Status),
+		"repos":       auditJSON(feature.Repos),
+		"target_date": auditDate(feature.TargetDate),
+	}
+}
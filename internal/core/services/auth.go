@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/ports"
+)
+
+// apiTokenPrefix starts every personal API token, which tells them apart from
+// session tokens and lets secret scanners spot leaked ones.
+const apiTokenPrefix = "pat_"
+
+// AuthService authenticates requests with personal API tokens, stored hashed
+// in api_tokens, or with session tokens issued in exchange for one.
+type AuthService struct {
+	queries    *db.Queries
+	sessions   ports.SessionTokens
+	sessionTTL time.Duration
+}
+
+func NewAuthService(queries *db.Queries, sessions ports.SessionTokens, sessionTTL time.Duration) *AuthService {
+	return &AuthService{queries: queries, sessions: sessions, sessionTTL: sessionTTL}
+}
+
+// Authenticate returns the user a bearer token belongs to.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (db.User, error) {
+	var userID pgt.UUID
+	if strings.HasPrefix(token, apiTokenPrefix) {
+		apiToken, err := s.queries.GetActiveAPITokenByHash(ctx, hashAPIToken(token))
+		if err != nil {
+			if errors.Is(notFound(err), ErrNotFound) {
+				return db.User{}, fmt.Errorf("%w: unknown, expired or revoked API token", ErrUnauthenticated)
+			}
+			return db.User{}, fmt.Errorf("failed to look up API token: %w", err)
+		}
+		if err := s.queries.TouchAPIToken(ctx, apiToken.ID); err != nil {
+			fmt.Printf("AuthService: Failed to record use of API token %v: %v\n", apiToken.ID, err)
+		}
+		userID = apiToken.UserID
+	} else {
+		subject, err := s.sessions.Verify(token)
+		if err != nil {
+			return db.User{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		id, err := uuid.Parse(subject)
+		if err != nil {
+			return db.User{}, fmt.Errorf("%w: session token has an invalid subject", ErrUnauthenticated)
+		}
+		userID = pgt.UUID{Bytes: id, Valid: true}
+	}
+
+	user, err := s.queries.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(notFound(err), ErrNotFound) {
+			return db.User{}, fmt.Errorf("%w: user no longer exists", ErrUnauthenticated)
+		}
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateSession issues a session token for the authenticated user.
+func (s *AuthService) CreateSession(ctx context.Context) (string, time.Time, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return "", time.Time{}, ErrUnauthenticated
+	}
+	expiresAt := time.Now().Add(s.sessionTTL)
+	token, err := s.sessions.Issue(uuid.UUID(user.ID.Bytes).String(), expiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to issue session token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// CreateAPIToken creates an API token for the authenticated user. The
+// returned string is the token itself, which can't be recovered later.
+func (s *AuthService) CreateAPIToken(ctx context.Context, name string, expiresAt pgt.Timestamptz) (db.ApiToken, string, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return db.ApiToken{}, "", ErrUnauthenticated
+	}
+	return s.CreateAPITokenForUser(ctx, user.ID, name, expiresAt)
+}
+
+// CreateAPITokenForUser creates an API token for any user. It exists to
+// bootstrap the first token from the command line.
+func (s *AuthService) CreateAPITokenForUser(ctx context.Context, userID pgt.UUID, name string, expiresAt pgt.Timestamptz) (db.ApiToken, string, error) {
+	if name == "" {
+		return db.ApiToken{}, "", fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		return db.ApiToken{}, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidInput)
+	}
+	if _, err := s.queries.GetUser(ctx, userID); err != nil {
+		return db.ApiToken{}, "", fmt.Errorf("failed to get user: %w", notFound(err))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return db.ApiToken{}, "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	apiToken, err := s.queries.CreateAPIToken(ctx, db.CreateAPITokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAPIToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return db.ApiToken{}, "", fmt.Errorf("failed to create API token: %w", err)
+	}
+	return apiToken, token, nil
+}
+
+// ListAPITokens returns the authenticated user's API tokens, revoked ones
+// included.
+func (s *AuthService) ListAPITokens(ctx context.Context) ([]db.ApiToken, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	tokens, err := s.queries.ListAPITokensByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes one of the authenticated user's API tokens.
+func (s *AuthService) RevokeAPIToken(ctx context.Context, id pgt.UUID) (db.ApiToken, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return db.ApiToken{}, ErrUnauthenticated
+	}
+	token, err := s.queries.RevokeAPIToken(ctx, db.RevokeAPITokenParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return db.ApiToken{}, fmt.Errorf("failed to revoke API token: %w", notFound(err))
+	}
+	return token, nil
+}
+
+// hashAPIToken returns the hex SHA-256 of a token. API tokens are random and
+// long, so a fast unsalted hash is enough to keep them useless if leaked.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// Author names shown on comments that aren't written by a user.
+const (
+	agentAuthorName  = "AI agent"
+	systemAuthorName = "System"
+)
+
+// CommentService manages the discussion on tasks. People and the coding
+// agent post to the same thread, so a task has one conversation.
+type CommentService struct {
+	queries *db.Queries
+}
+
+func NewCommentService(queries *db.Queries) *CommentService {
+	return &CommentService{queries: queries}
+}
+
+// ListComments returns the task's comments, oldest first. Replies carry the
+// ID of the comment they answer in ParentID.
+func (s *CommentService) ListComments(ctx context.Context, taskID pgt.UUID) ([]db.TaskComment, error) {
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	comments, err := s.queries.ListTaskComments(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task comments: %w", err)
+	}
+	return comments, nil
+}
+
+// CreateComment posts a Markdown comment on the task as the current user. A
+// valid parentID makes it a reply, and the parent must be on the same task.
+func (s *CommentService) CreateComment(ctx context.Context, taskID, parentID pgt.UUID, body string) (db.TaskComment, error) {
+	if err := requireMember(ctx, "commenting on tasks"); err != nil {
+		return db.TaskComment{}, err
+	}
+	if strings.TrimSpace(body) == "" {
+		return db.TaskComment{}, fmt.Errorf("%w: body is required", ErrInvalidInput)
+	}
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return db.TaskComment{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	if parentID.Valid {
+		_, err := s.queries.GetTaskComment(ctx, db.GetTaskCommentParams{ID: parentID, TaskID: taskID})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.TaskComment{}, fmt.Errorf("%w: parent comment is not on this task", ErrValidation)
+		}
+		if err != nil {
+			return db.TaskComment{}, fmt.Errorf("failed to get parent comment: %w", err)
+		}
+	}
+
+	arg := db.CreateTaskCommentParams{
+		TaskID:     taskID,
+		ParentID:   parentID,
+		AuthorName: systemAuthorName,
+		Body:       body,
+	}
+	if user, ok := UserFromContext(ctx); ok {
+		arg.AuthorID = user.ID
+		arg.AuthorName = user.Name
+	}
+	comment, err := s.queries.CreateTaskComment(ctx, arg)
+	if err != nil {
+		return db.TaskComment{}, fmt.Errorf("failed to create task comment: %w", err)
+	}
+	return comment, nil
+}
+
+// UpdateComment replaces the comment's body and stamps it as edited. Only
+// its author and admins may edit a comment.
+func (s *CommentService) UpdateComment(ctx context.Context, taskID, id pgt.UUID, body string) (db.TaskComment, error) {
+	if strings.TrimSpace(body) == "" {
+		return db.TaskComment{}, fmt.Errorf("%w: body is required", ErrInvalidInput)
+	}
+	comment, err := s.queries.GetTaskComment(ctx, db.GetTaskCommentParams{ID: id, TaskID: taskID})
+	if err != nil {
+		return db.TaskComment{}, fmt.Errorf("failed to get task comment: %w", notFound(err))
+	}
+	if err := requireAuthor(ctx, comment.AuthorID, "editing the comment"); err != nil {
+		return db.TaskComment{}, err
+	}
+	comment, err = s.queries.UpdateTaskComment(ctx, db.UpdateTaskCommentParams{ID: id, Body: body})
+	if err != nil {
+		return db.TaskComment{}, fmt.Errorf("failed to update task comment: %w", notFound(err))
+	}
+	return comment, nil
+}
+
+// DeleteComment deletes the comment and its replies. Only its author and
+// admins may delete a comment.
+func (s *CommentService) DeleteComment(ctx context.Context, taskID, id pgt.UUID) error {
+	comment, err := s.queries.GetTaskComment(ctx, db.GetTaskCommentParams{ID: id, TaskID: taskID})
+	if err != nil {
+		return fmt.Errorf("failed to get task comment: %w", notFound(err))
+	}
+	if err := requireAuthor(ctx, comment.AuthorID, "deleting the comment"); err != nil {
+		return err
+	}
+	if err := s.queries.DeleteTaskComment(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete task comment: %w", err)
+	}
+	return nil
+}
+
+// PostAgentSummary posts the summary of a finished agent run on its task.
+func (s *CommentService) PostAgentSummary(ctx context.Context, run db.AgentRun, summary string) (db.TaskComment, error) {
+	comment, err := s.queries.CreateTaskComment(ctx, db.CreateTaskCommentParams{
+		TaskID:     run.TaskID,
+		AuthorID:   AgentUserID,
+		AuthorName: agentAuthorName,
+		AgentRunID: run.ID,
+		Body:       summary,
+	})
+	if err != nil {
+		return db.TaskComment{}, fmt.Errorf("failed to post agent summary: %w", err)
+	}
+	return comment, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+)
+
+// cancelledStatus is the status of tasks that won't be done.
+const cancelledStatus = domain.StatusCancelled
+
+// closedStatuses are the statuses in which a task no longer blocks others.
+var closedStatuses = []string{doneStatus, cancelledStatus}
+
+// AddTaskDependency records that blockerID blocks the task. Dependencies
+// that would create a cycle are rejected. Adding an existing dependency is a
+// no-op.
+func (s *TaskService) AddTaskDependency(ctx context.Context, id, blockerID pgt.UUID) (db.Task, error) {
+	if err := requireMember(ctx, "changing task dependencies"); err != nil {
+		return db.Task{}, err
+	}
+	if id == blockerID {
+		return db.Task{}, fmt.Errorf("%w: a task can't block itself", ErrValidation)
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if err := q.LockTaskDependencies(ctx); err != nil {
+			return fmt.Errorf("failed to lock task dependencies: %w", err)
+		}
+		var err error
+		task, err = q.GetTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		blocker, err := q.GetTask(ctx, blockerID)
+		if err != nil {
+			return fmt.Errorf("failed to get blocking task: %w", notFound(err))
+		}
+		cycle, err := q.TaskBlocks(ctx, db.TaskBlocksParams{BlockerID: id, BlockedID: blockerID})
+		if err != nil {
+			return fmt.Errorf("failed to check for dependency cycles: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("%w: %q already depends on %q, the dependency would create a cycle", ErrValidation, blocker.Name, task.Name)
+		}
+
+		_, err = q.AddTaskDependency(ctx, db.AddTaskDependencyParams{BlockerID: blockerID, BlockedID: id})
+		if err != nil {
+			return fmt.Errorf("failed to add task dependency: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntityTask, id, AuditActionDependencyAdded, nil, map[string]any{"blocked_by": auditUUID(blockerID)})
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// RemoveTaskDependency removes the dependency of the task on blockerID.
+func (s *TaskService) RemoveTaskDependency(ctx context.Context, id, blockerID pgt.UUID) (db.Task, error) {
+	if err := requireMember(ctx, "changing task dependencies"); err != nil {
+		return db.Task{}, err
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		task, err = q.GetTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		n, err := q.RemoveTaskDependency(ctx, db.RemoveTaskDependencyParams{BlockerID: blockerID, BlockedID: id})
+		if err != nil {
+			return fmt.Errorf("failed to remove task dependency: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: the task isn't blocked by %s", ErrNotFound, uuid.UUID(blockerID.Bytes))
+		}
+		return recordAudit(ctx, q, AuditEntityTask, id, AuditActionDependencyRemoved, map[string]any{"blocked_by": auditUUID(blockerID)}, nil)
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// ListDependenciesByTask loads the dependencies of several tasks with one
+// query and groups them by task ID. A dependency is listed under both the
+// blocking and the blocked task when both were asked for.
+func (s *TaskService) ListDependenciesByTask(ctx context.Context, taskIDs []pgt.UUID) (map[pgt.UUID][]db.ListTaskDependencyLinksRow, error) {
+	links, err := s.queries.ListTaskDependencyLinks(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task dependencies: %w", err)
+	}
+	wanted := make(map[pgt.UUID]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		wanted[id] = true
+	}
+	byTask := make(map[pgt.UUID][]db.ListTaskDependencyLinksRow, len(taskIDs))
+	for _, link := range links {
+		if wanted[link.BlockerID] {
+			byTask[link.BlockerID] = append(byTask[link.BlockerID], link)
+		}
+		if wanted[link.BlockedID] {
+			byTask[link.BlockedID] = append(byTask[link.BlockedID], link)
+		}
+	}
+	return byTask, nil
+}
+
+// DependencyGraph returns the dependencies between the feature's tasks. Tasks
+// of other features that block, or are blocked by, one of them are included
+// as external nodes.
+func (s *TaskService) DependencyGraph(ctx context.Context, featureID pgt.UUID) (domain.DependencyGraph, error) {
+	feature, err := s.queries.GetFeature(ctx, featureID)
+	if err != nil {
+		return domain.DependencyGraph{}, fmt.Errorf("failed to get feature: %w", notFound(err))
+	}
+	tasks, err := s.queries.ListFeatureTasks(ctx, featureID)
+	if err != nil {
+		return domain.DependencyGraph{}, fmt.Errorf("failed to list feature tasks: %w", err)
+	}
+	taskIDs := make([]pgt.UUID, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+	links, err := s.queries.ListTaskDependencyLinks(ctx, taskIDs)
+	if err != nil {
+		return domain.DependencyGraph{}, fmt.Errorf("failed to list task dependencies: %w", err)
+	}
+
+	graph := domain.DependencyGraph{
+		Name:  feature.Name,
+		Nodes: make([]domain.DependencyNode, 0, len(tasks)),
+		Edges: make([]domain.DependencyEdge, 0, len(links)),
+	}
+	seen := make(map[pgt.UUID]bool, len(tasks))
+	addNode := func(id pgt.UUID, name string, status pgt.Text, nodeFeatureID pgt.UUID) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		graph.Nodes = append(graph.Nodes, domain.DependencyNode{
+			ID:        uuid.UUID(id.Bytes).String(),
+			Name:      name,
+			Status:    status.String,
+			FeatureID: uuid.UUID(nodeFeatureID.Bytes).String(),
+			External:  nodeFeatureID != featureID,
+		})
+	}
+	for _, task := range tasks {
+		addNode(task.ID, task.Name, task.Status, task.FeatureID)
+	}
+	for _, link := range links {
+		addNode(link.BlockerID, link.BlockerName, link.BlockerStatus, link.BlockerFeatureID)
+		addNode(link.BlockedID, link.BlockedName, link.BlockedStatus, link.BlockedFeatureID)
+		graph.Edges = append(graph.Edges, domain.DependencyEdge{
+			From: uuid.UUID(link.BlockerID.Bytes).String(),
+			To:   uuid.UUID(link.BlockedID.Bytes).String(),
+		})
+	}
+	return graph, nil
+}
+
+// checkBlockers rejects starting or finishing a task while a task blocking it
+// is still open.
+func checkBlockers(ctx context.Context, q *db.Queries, id pgt.UUID, to string) error {
+	if to != inProgressStatus && to != doneStatus {
+		return nil
+	}
+	blockers, err := q.ListOpenBlockers(ctx, db.ListOpenBlockersParams{BlockedID: id, ClosedStatuses: closedStatuses})
+	if err != nil {
+		return fmt.Errorf("failed to list blocking tasks: %w", err)
+	}
+	if len(blockers) == 0 {
+		return nil
+	}
+	names := make([]string, len(blockers))
+	for i, blocker := range blockers {
+		names[i] = fmt.Sprintf("%q", blocker.Name)
+	}
+	return fmt.Errorf("%w: cannot move to %q while blocked by %s", ErrInvalidTransition, to, strings.Join(names, ", "))
+}
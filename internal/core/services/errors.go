@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is returned when the caller supplied arguments the
+	// service can't act on. It is always wrapped with a description.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrValidation is returned when a field holds a value the domain
+	// doesn't accept, such as an unknown status.
+	ErrValidation = errors.New("validation failed")
+	// ErrInvalidTransition is returned when the workflow doesn't allow the
+	// requested status change from the current status.
+	ErrInvalidTransition = errors.New("status transition not allowed")
+	// ErrConflict is returned when the change clashes with the current state
+	// of other rows, such as deleting a feature that still has tasks.
+	ErrConflict = errors.New("conflict")
+	// ErrUpstream is returned when an external service the request depends
+	// on, such as GitHub, fails.
+	ErrUpstream = errors.New("upstream service failed")
+	// ErrUnauthenticated is returned when a request carries no credentials,
+	// or ones that are invalid, expired or revoked.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the acting user's role doesn't allow the
+	// change. It is always wrapped in a PermissionError.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// FeatureHasTasksError is returned when a feature can't be deleted because
+// tasks still reference it. It matches ErrConflict with errors.Is.
+type FeatureHasTasksError struct {
+	TaskIDs []pgt.UUID
+}
+
+func (e *FeatureHasTasksError) Error() string {
+	return fmt.Sprintf("%v: feature still has %d tasks", ErrConflict, len(e.TaskIDs))
+}
+
+func (e *FeatureHasTasksError) Unwrap() error {
+	return ErrConflict
+}
+
+// PermissionError is returned when the policy denies an action. Reason tells
+// the caller what it would take. It matches ErrForbidden with errors.Is.
+type PermissionError struct {
+	Reason string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrForbidden, e.Reason)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return ErrForbidden
+}
+
+// notFound translates pgx.ErrNoRows into ErrNotFound so adapters don't need to
+// know about the database driver.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint, such
+// as two labels with the same name.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err comes from a foreign key that
+// restricts deletes, such as deleting a user who still owns features.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// AddFeatureOwner makes the given user an owner of the feature. The user's
+// name and role are copied onto the feature_owners row so owner lists can be
+// served without joining users. Only the feature's owners and admins may
+// add owners.
+func (s *FeatureService) AddFeatureOwner(ctx context.Context, featureID pgt.UUID, userID pgt.UUID) (db.FeatureOwner, error) {
+	if _, err := s.queries.GetFeature(ctx, featureID); err != nil {
+		return db.FeatureOwner{}, fmt.Errorf("failed to get feature: %w", notFound(err))
+	}
+	if err := requireFeatureOwner(ctx, s.queries, featureID, "changing the feature's owners"); err != nil {
+		return db.FeatureOwner{}, err
+	}
+	user, err := s.queries.GetUser(ctx, userID)
+	if err != nil {
+		return db.FeatureOwner{}, fmt.Errorf("failed to get user: %w", notFound(err))
+	}
+
+	var owner db.FeatureOwner
+	err = withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		owner, err = q.AddFeatureOwner(ctx, db.AddFeatureOwnerParams{
+			FeatureID: featureID,
+			UserID:    userID,
+			UserName:  pgt.Text{String: user.Name, Valid: true},
+			UserRole:  pgt.Text{String: user.Role, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add feature owner: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, featureID, AuditActionOwnerAdded, nil, map[string]any{"owner": auditUUID(userID)})
+	})
+	if err != nil {
+		return db.FeatureOwner{}, err
+	}
+	return owner, nil
+}
+
+// RemoveFeatureOwner removes an owner from the feature. Only the feature's
+// owners and admins may remove owners.
+func (s *FeatureService) RemoveFeatureOwner(ctx context.Context, featureID pgt.UUID, userID pgt.UUID) error {
+	return withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if err := requireFeatureOwner(ctx, q, featureID, "changing the feature's owners"); err != nil {
+			return err
+		}
+		err := q.RemoveFeatureOwner(ctx, db.RemoveFeatureOwnerParams{
+			FeatureID: featureID,
+			UserID:    userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove feature owner: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, featureID, AuditActionOwnerRemoved, map[string]any{"owner": auditUUID(userID)}, nil)
+	})
+}
+
+func (s *FeatureService) ListFeatureOwners(ctx context.Context, featureID pgt.UUID) ([]db.FeatureOwner, error) {
+	owners, err := s.queries.ListFeatureOwners(ctx, featureID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature owners: %w", err)
+	}
+	return owners, nil
+}
+
+// ListOwnersByFeature loads the owners of several features with one query and
+// groups them by feature ID.
+func (s *FeatureService) ListOwnersByFeature(ctx context.Context, featureIDs []pgt.UUID) (map[pgt.UUID][]db.FeatureOwner, error) {
+	owners, err := s.queries.ListFeatureOwnersByFeatureIDs(ctx, featureIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature owners: %w", err)
+	}
+
+	byFeature := make(map[pgt.UUID][]db.FeatureOwner, len(featureIDs))
+	for _, owner := range owners {
+		byFeature[owner.FeatureID] = append(byFeature[owner.FeatureID], owner)
+	}
+	return byFeature, nil
+}
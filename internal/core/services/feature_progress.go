@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+)
+
+// FeatureProgress counts a feature's tasks, subtasks included, per status
+// and sums their estimates. Tasks without a status are counted under "".
+type FeatureProgress struct {
+	Total    int64
+	ByStatus map[string]int64
+	// PointsDone and PointsRemaining sum the estimates of the done tasks and
+	// of the open ones; cancelled tasks count for neither.
+	PointsDone      int64
+	PointsRemaining int64
+}
+
+// Done is the number of done tasks.
+func (p FeatureProgress) Done() int64 {
+	return p.ByStatus[doneStatus]
+}
+
+// Percent is the share of tasks that are done, rounded down. Cancelled tasks
+// don't count, as for SubtaskProgress.
+func (p FeatureProgress) Percent() int {
+	total := p.Total - p.ByStatus[cancelledStatus]
+	if total <= 0 {
+		return 0
+	}
+	return int(p.Done() * 100 / total)
+}
+
+// Complete reports whether the feature has tasks and all of them are done,
+// cancelled ones aside.
+func (p FeatureProgress) Complete() bool {
+	total := p.Total - p.ByStatus[cancelledStatus]
+	return total > 0 && p.Done() == total
+}
+
+// listFeatureProgress rolls up the tasks of several features with one query.
+// Features without tasks get an empty FeatureProgress.
+func listFeatureProgress(ctx context.Context, q *db.Queries, featureIDs []pgt.UUID) (map[pgt.UUID]FeatureProgress, error) {
+	rows, err := q.ListFeatureProgress(ctx, featureIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up feature tasks: %w", err)
+	}
+	progress := make(map[pgt.UUID]FeatureProgress, len(featureIDs))
+	for _, id := range featureIDs {
+		progress[id] = FeatureProgress{ByStatus: map[string]int64{}}
+	}
+	for _, row := range rows {
+		p := progress[row.FeatureID]
+		p.Total += row.Tasks
+		p.ByStatus[row.Status.String] += row.Tasks
+		switch row.Status.String {
+		case doneStatus:
+			p.PointsDone += row.Points
+		case cancelledStatus:
+		default:
+			p.PointsRemaining += row.Points
+		}
+		progress[row.FeatureID] = p
+	}
+	return progress, nil
+}
+
+// deriveFeatureStatus moves the feature to done when the workflow config asks
+// for it and all of the feature's tasks are done. The feature workflow's
+// transitions are skipped, since nobody chose the status, but
+// WorkflowConfig.Validate makes sure it has a done status. Features are never
+// moved back out of done; reopening a feature is left to its owners.
+//
+// Callers lock the feature with lockTask before changing the task, so when
+// two of its tasks are finished at the same time the second transaction
+// waits for the first and sees both tasks done.
+func (s *TaskService) deriveFeatureStatus(ctx context.Context, q *db.Queries, featureID pgt.UUID) error {
+	if !s.deriveFeatures || !featureID.Valid {
+		return nil
+	}
+	current, err := q.GetFeature(ctx, featureID)
+	if errors.Is(notFound(err), ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feature: %w", err)
+	}
+	if current.Status.String == domain.StatusDone {
+		return nil
+	}
+	progress, err := listFeatureProgress(ctx, q, []pgt.UUID{featureID})
+	if err != nil {
+		return err
+	}
+	if !progress[featureID].Complete() {
+		return nil
+	}
+	feature, err := q.SetFeatureStatus(ctx, db.SetFeatureStatusParams{
+		ID:     featureID,
+		Status: pgt.Text{String: domain.StatusDone, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete feature: %w", err)
+	}
+	fmt.Printf("TaskService: All tasks of feature %v are done, feature moved to %s\n", featureID, domain.StatusDone)
+	return recordAudit(ctx, q, AuditEntityFeature, feature.ID, AuditActionUpdate, featureAuditFields(current), featureAuditFields(feature))
+}
+
+// lockTask locks the task for a change that may complete its feature, or
+// the features in moveTo. Features are locked before the task, in the order
+// of lockFeatures, since FeatureService.DeleteFeature locks a feature and
+// then its tasks; taking them the other way around deadlocks. Without
+// derived feature status only the task is locked. A task moved to another
+// feature while its features were locked is a conflict.
+func (s *TaskService) lockTask(ctx context.Context, q *db.Queries, id pgt.UUID, moveTo ...pgt.UUID) (db.Task, error) {
+	if !s.deriveFeatures {
+		task, err := q.GetTaskForUpdate(ctx, id)
+		if err != nil {
+			return db.Task{}, fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		return task, nil
+	}
+	current, err := q.GetTask(ctx, id)
+	if err != nil {
+		return db.Task{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	if _, err := lockFeatures(ctx, q, append(moveTo, current.FeatureID)...); err != nil {
+		return db.Task{}, err
+	}
+	task, err := q.GetTaskForUpdate(ctx, id)
+	if err != nil {
+		return db.Task{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	if task.FeatureID != current.FeatureID {
+		return db.Task{}, fmt.Errorf("%w: the task was moved to another feature meanwhile, try again", ErrConflict)
+	}
+	return task, nil
+}
+
+// lockFeatures locks the features in ID order, so transactions locking
+// several features can't deadlock each other, and returns those outside the
+// trash. Invalid and repeated IDs are skipped.
+func lockFeatures(ctx context.Context, q *db.Queries, ids ...pgt.UUID) (map[pgt.UUID]db.Feature, error) {
+	ids = slices.DeleteFunc(slices.Clone(ids), func(id pgt.UUID) bool { return !id.Valid })
+	slices.SortFunc(ids, func(a, b pgt.UUID) int { return bytes.Compare(a.Bytes[:], b.Bytes[:]) })
+	features := make(map[pgt.UUID]db.Feature, len(ids))
+	for _, id := range slices.Compact(ids) {
+		feature, err := q.GetFeatureForUpdate(ctx, id)
+		if errors.Is(notFound(err), ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock feature: %w", err)
+		}
+		features[id] = feature
+	}
+	return features, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+// testPool connects to TEST_DB_URL, a scratch database migrated with
+// db/migrations, and skips the test when it isn't set.
+func testPool(t *testing.T) (*db.Queries, *pgxpool.Pool) {
+	t.Helper()
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL isn't set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("failed to connect to TEST_DB_URL: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return db.New(pool), pool
+}
+
+// newDerivedFeature creates a feature with n tasks in review, with derived
+// feature status turned on. The context has no user, so it acts as the
+// system.
+func newDerivedFeature(t *testing.T, n int) (*TaskService, *FeatureService, db.Feature, []db.Task) {
+	t.Helper()
+	ctx := context.Background()
+	queries, pool := testPool(t)
+	workflows := domain.DefaultWorkflowConfig()
+	workflows.DeriveFeatureStatus = true
+	tasks := NewTaskService(queries, pool, workflows, DefaultAgentConfig())
+	features := NewFeatureService(queries, pool, workflows.Feature)
+
+	feature, err := features.CreateFeature(ctx, db.CreateFeatureParams{Name: "Derived " + uuid.NewString()})
+	if err != nil {
+		t.Fatalf("CreateFeature: %v", err)
+	}
+	created := make([]db.Task, n)
+	for i := range created {
+		created[i], err = tasks.CreateTask(ctx, db.CreateTaskParams{
+			Name:        "Task " + uuid.NewString(),
+			FeatureID:   feature.ID,
+			FeatureName: pgt.Text{String: feature.Name, Valid: true},
+			Status:      pgt.Text{String: reviewStatus, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+	}
+	return tasks, features, feature, created
+}
+
+// concurrently runs each function in its own goroutine, released together.
+func concurrently(fns ...func() error) []error {
+	errs := make([]error, len(fns))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func TestDeriveFeatureStatusConcurrentCompletions(t *testing.T) {
+	ctx := context.Background()
+	for range 20 {
+		tasks, features, feature, created := newDerivedFeature(t, 2)
+		finish := func(task db.Task) func() error {
+			return func() error {
+				_, err := tasks.UpdateTask(ctx, db.UpdateTaskParams{ID: task.ID, Status: pgt.Text{String: doneStatus, Valid: true}})
+				return err
+			}
+		}
+		for _, err := range concurrently(finish(created[0]), finish(created[1])) {
+			if err != nil {
+				t.Fatalf("UpdateTask: %v", err)
+			}
+		}
+		feature, err := features.GetFeature(ctx, feature.ID)
+		if err != nil {
+			t.Fatalf("GetFeature: %v", err)
+		}
+		if feature.Status.String != domain.StatusDone {
+			t.Fatalf("feature status = %q after both tasks were done, want %q", feature.Status.String, domain.StatusDone)
+		}
+	}
+}
+
+func TestDeriveFeatureStatusWithConcurrentFeatureDelete(t *testing.T) {
+	ctx := context.Background()
+	for range 20 {
+		tasks, features, feature, created := newDerivedFeature(t, 1)
+		errs := concurrently(
+			func() error {
+				_, err := tasks.UpdateTask(ctx, db.UpdateTaskParams{ID: created[0].ID, Status: pgt.Text{String: doneStatus, Valid: true}})
+				return err
+			},
+			func() error {
+				return features.DeleteFeature(ctx, feature.ID, ports.DeleteFeatureOptions{Mode: ports.FeatureDeleteCascade})
+			},
+		)
+		// The task may already be in the trash when it is finished.
+		if err := errs[0]; err != nil && !errors.Is(err, ErrNotFound) {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+		if err := errs[1]; err != nil {
+			t.Fatalf("DeleteFeature: %v", err)
+		}
+	}
+}
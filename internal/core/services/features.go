@@ -7,18 +7,45 @@ import (
 
 	"github.com/google/uuid"
 	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
 )
 
 type FeatureService struct {
-	queries *db.Queries
+	queries  *db.Queries
+	pool     *pgxpool.Pool
+	workflow domain.Workflow
 }
 
-func NewFeatureService(queries *db.Queries) *FeatureService {
-	return &FeatureService{queries: queries}
+func NewFeatureService(queries *db.Queries, pool *pgxpool.Pool, workflow domain.Workflow) *FeatureService {
+	return &FeatureService{queries: queries, pool: pool, workflow: workflow}
 }
 
+// CreateFeature creates a feature in the workflow's initial status unless
+// another known status is given. Linked repos are optional. The feature is
+// attributed to the authenticated user, who becomes its first owner.
 func (s *FeatureService) CreateFeature(ctx context.Context, arg db.CreateFeatureParams) (db.Feature, error) {
+	if err := requireMember(ctx, "creating features"); err != nil {
+		return db.Feature{}, err
+	}
+	arg.CreatedBy = actorFromContext(ctx)
+	if !arg.Status.Valid {
+		arg.Status = pgt.Text{String: s.workflow.InitialStatus, Valid: true}
+	}
+	if err := checkStatusChange(s.workflow, pgt.Text{}, arg.Status.String); err != nil {
+		return db.Feature{}, err
+	}
+	if err := checkPriority(s.workflow, arg.Priority); err != nil {
+		return db.Feature{}, err
+	}
+	repos, err := normalizeRepos(arg.Repos)
+	if err != nil {
+		return db.Feature{}, err
+	}
+	arg.Repos = repos
+
 	// Generate a new UUID for the feature
 	newUUID, err := uuid.NewRandom()
 	if err != nil {
@@ -28,42 +55,232 @@ func (s *FeatureService) CreateFeature(ctx context.Context, arg db.CreateFeature
 	arg.CreatedAt = pgt.Timestamptz{Time: time.Now(), Valid: true}
 	arg.UpdatedAt = pgt.Timestamptz{Time: time.Now(), Valid: true}
 
-	feature, err := s.queries.CreateFeature(ctx, arg)
+	var feature db.Feature
+	err = withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		feature, err = q.CreateFeature(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to create feature: %w", err)
+		}
+		if user, ok := UserFromContext(ctx); ok {
+			_, err = q.AddFeatureOwner(ctx, db.AddFeatureOwnerParams{
+				FeatureID: feature.ID,
+				UserID:    user.ID,
+				UserName:  pgt.Text{String: user.Name, Valid: true},
+				UserRole:  pgt.Text{String: user.Role, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add feature owner: %w", err)
+			}
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, feature.ID, AuditActionCreate, nil, featureAuditFields(feature))
+	})
 	if err != nil {
-		return db.Feature{}, fmt.Errorf("failed to create feature: %w", err)
+		return db.Feature{}, err
 	}
 	return feature, nil
 }
 
-func (s *FeatureService) ListFeatures(ctx context.Context) ([]db.Feature, error) {
-	features, err := s.queries.ListFeatures(ctx)
+// ListFeatures returns one page of the features matching the filters in arg.
+// The sort and cursor fields of arg are filled in from page. The returned
+// cursor is empty when there are no more features.
+func (s *FeatureService) ListFeatures(ctx context.Context, arg db.ListFeaturesParams, page ports.PageRequest) ([]db.Feature, string, error) {
+	p, err := parsePage(page)
+	if err != nil {
+		return nil, "", err
+	}
+	arg.SortBy = p.sortBy
+	arg.Descending = p.descending()
+	arg.CursorTime = p.cursorTime
+	arg.CursorText = p.cursorText
+	arg.CursorID = p.cursorID
+	arg.PageLimit = p.queryLimit()
+
+	features, err := s.queries.ListFeatures(ctx, arg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list features: %w", err)
+		return nil, "", fmt.Errorf("failed to list features: %w", err)
+	}
+
+	if len(features) <= p.limit {
+		return features, "", nil
 	}
-	return features, nil
+	features = features[:p.limit]
+	last := features[len(features)-1]
+	sortTime, sortText := p.sortValues(last.CreatedAt, last.UpdatedAt, last.Name, last.Priority, last.Status)
+	return features, p.nextCursor(last.ID, sortTime, sortText), nil
 }
 
+// UpdateFeature replaces the feature's fields. The status and linked repos
+// are kept when none are given, and a status change must be allowed by the
+// feature workflow. Only the feature's owners and admins may update it.
 func (s *FeatureService) UpdateFeature(ctx context.Context, arg db.UpdateFeatureParams) (db.Feature, error) {
+	if err := checkPriority(s.workflow, arg.Priority); err != nil {
+		return db.Feature{}, err
+	}
+	if arg.Repos != nil {
+		repos, err := normalizeRepos(arg.Repos)
+		if err != nil {
+			return db.Feature{}, err
+		}
+		arg.Repos = repos
+	}
 	arg.UpdatedAt = pgt.Timestamptz{Time: time.Now(), Valid: true}
-	feature, err := s.queries.UpdateFeature(ctx, arg)
+
+	var feature db.Feature
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		current, err := q.GetFeatureForUpdate(ctx, arg.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update feature: %w", notFound(err))
+		}
+		if err := requireFeatureOwner(ctx, q, arg.ID, "updating the feature"); err != nil {
+			return err
+		}
+		if arg.Repos == nil {
+			arg.Repos = current.Repos
+		}
+		if !arg.Status.Valid {
+			arg.Status = current.Status
+		} else if err := checkStatusChange(s.workflow, current.Status, arg.Status.String); err != nil {
+			return err
+		}
+
+		feature, err = q.UpdateFeature(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to update feature: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, feature.ID, AuditActionUpdate, featureAuditFields(current), featureAuditFields(feature))
+	})
 	if err != nil {
-		return db.Feature{}, fmt.Errorf("failed to update feature: %w", err)
+		return db.Feature{}, err
 	}
 	return feature, nil
 }
 
-func (s *FeatureService) DeleteFeature(ctx context.Context, id pgt.UUID) error {
-	err := s.queries.DeleteFeature(ctx, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete feature: %w", err)
+// DeleteFeature moves the feature to the trash. What happens to its tasks
+// depends on opts.Mode: by default a feature that still has tasks isn't
+// deleted and a *FeatureHasTasksError lists them, cascade moves the tasks to
+// the trash as well and reassign moves them to opts.ReassignTo. Everything
+// runs in one transaction. Only the feature's owners and admins may delete
+// it.
+func (s *FeatureService) DeleteFeature(ctx context.Context, id pgt.UUID, opts ports.DeleteFeatureOptions) error {
+	switch opts.Mode {
+	case ports.FeatureDeleteRestrict, ports.FeatureDeleteCascade:
+	case ports.FeatureDeleteReassign:
+		if !opts.ReassignTo.Valid {
+			return fmt.Errorf("%w: reassign needs a target feature", ErrInvalidInput)
+		}
+		if opts.ReassignTo == id {
+			return fmt.Errorf("%w: can't reassign tasks to the feature being deleted", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: unknown delete mode %q", ErrInvalidInput, opts.Mode)
 	}
-	return nil
+
+	return withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		// Both features are locked before the tasks, see TaskService.lockTask.
+		features, err := lockFeatures(ctx, q, id, opts.ReassignTo)
+		if err != nil {
+			return err
+		}
+		current, ok := features[id]
+		if !ok {
+			return fmt.Errorf("failed to delete feature: %w", ErrNotFound)
+		}
+		if err := requireFeatureOwner(ctx, q, id, "deleting the feature"); err != nil {
+			return err
+		}
+		tasks, err := q.ListFeatureTasksForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list feature tasks: %w", err)
+		}
+
+		switch opts.Mode {
+		case ports.FeatureDeleteRestrict:
+			if len(tasks) > 0 {
+				taskIDs := make([]pgt.UUID, len(tasks))
+				for i, task := range tasks {
+					taskIDs[i] = task.ID
+				}
+				return &FeatureHasTasksError{TaskIDs: taskIDs}
+			}
+		case ports.FeatureDeleteCascade:
+			for _, task := range tasks {
+				if err := q.DeleteTask(ctx, task.ID); err != nil {
+					return fmt.Errorf("failed to delete task: %w", err)
+				}
+				if err := recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionDelete, taskAuditFields(task), nil); err != nil {
+					return err
+				}
+			}
+		case ports.FeatureDeleteReassign:
+			target, ok := features[opts.ReassignTo]
+			if !ok {
+				return fmt.Errorf("%w: target feature %s not found", ErrInvalidInput, uuid.UUID(opts.ReassignTo.Bytes))
+			}
+			for _, task := range tasks {
+				moved, err := q.UpdateTask(ctx, db.UpdateTaskParams{
+					ID:          task.ID,
+					FeatureID:   target.ID,
+					FeatureName: pgt.Text{String: target.Name, Valid: true},
+				})
+				if err != nil {
+					return fmt.Errorf("failed to reassign task: %w", err)
+				}
+				if err := recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionUpdate, taskAuditFields(task), taskAuditFields(moved)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := q.DeleteFeature(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete feature: %w", err)
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, id, AuditActionDelete, featureAuditFields(current), nil)
+	})
 }
 
 func (s *FeatureService) GetFeature(ctx context.Context, id pgt.UUID) (db.Feature, error) {
 	feature, err := s.queries.GetFeature(ctx, id)
 	if err != nil {
-		return db.Feature{}, fmt.Errorf("failed to get feature: %w", err)
+		return db.Feature{}, fmt.Errorf("failed to get feature: %w", notFound(err))
 	}
 	return feature, nil
 }
+
+// FeatureDetails is what is shown with a feature besides its own columns.
+type FeatureDetails struct {
+	Owners   []db.FeatureOwner
+	Labels   []db.Label
+	Progress FeatureProgress
+}
+
+// ListFeatureDetails loads the details of several features, with one query
+// per kind of detail, and returns them by feature ID.
+func (s *FeatureService) ListFeatureDetails(ctx context.Context, featureIDs []pgt.UUID) (map[pgt.UUID]FeatureDetails, error) {
+	owners, err := s.ListOwnersByFeature(ctx, featureIDs)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := listLabelsByFeature(ctx, s.queries, featureIDs)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := listFeatureProgress(ctx, s.queries, featureIDs)
+	if err != nil {
+		return nil, err
+	}
+	details := make(map[pgt.UUID]FeatureDetails, len(featureIDs))
+	for _, id := range featureIDs {
+		details[id] = FeatureDetails{Owners: owners[id], Labels: labels[id], Progress: progress[id]}
+	}
+	return details, nil
+}
+
+// GetFeatureDetails loads the details of one feature, see ListFeatureDetails.
+func (s *FeatureService) GetFeatureDetails(ctx context.Context, id pgt.UUID) (FeatureDetails, error) {
+	details, err := s.ListFeatureDetails(ctx, []pgt.UUID{id})
+	if err != nil {
+		return FeatureDetails{}, err
+	}
+	return details[id], nil
+}
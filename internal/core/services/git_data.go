@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+)
+
+// Statuses tasks move to automatically as work on them progresses in git,
+// if the workflow has them and allows the move.
+const (
+	inProgressStatus = domain.StatusInProgress // commits were pushed or changes requested
+	reviewStatus     = domain.StatusReview     // a pull request is open
+	doneStatus       = domain.StatusDone       // the pull request was merged
+)
+
+// pullRequestStatus is the status a task moves to when one of its pull
+// requests is in the given state, or "" to keep the current one.
+func pullRequestStatus(state string) string {
+	switch state {
+	case domain.PullRequestOpen:
+		return reviewStatus
+	case domain.PullRequestMerged:
+		return doneStatus
+	}
+	return ""
+}
+
+// normalizeGitData validates submitted git data and returns it re-encoded, so
+// the stored JSON always has the same shape.
+func normalizeGitData(raw []byte) ([]byte, error) {
+	data, err := domain.ParseGitData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := data.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: invalid git_data: %v", ErrValidation, err)
+	}
+	return encodeGitData(data)
+}
+
+func encodeGitData(data domain.GitData) ([]byte, error) {
+	if data.Repos == nil {
+		data.Repos = []domain.GitRepo{}
+	}
+	if data.Branches == nil {
+		data.Branches = []domain.GitBranch{}
+	}
+	if data.Commits == nil {
+		data.Commits = []domain.GitCommit{}
+	}
+	if data.PullRequests == nil {
+		data.PullRequests = []domain.GitPullRequest{}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode git_data: %w", err)
+	}
+	return encoded, nil
+}
+
+// normalizeRepos validates a feature's linked repos and returns them encoded.
+func normalizeRepos(raw []byte) ([]byte, error) {
+	repos, err := domain.ParseRepos(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := domain.ValidateRepos(repos); err != nil {
+		return nil, fmt.Errorf("%w: invalid repos: %v", ErrValidation, err)
+	}
+	if repos == nil {
+		repos = []domain.GitRepo{}
+	}
+	encoded, err := json.Marshal(repos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode repos: %w", err)
+	}
+	return encoded, nil
+}
+
+// gitDataChange edits a task's git data. It may return a status to move the
+// task to, or "" to keep the current one, and ErrNotFound when the entry it
+// should remove isn't linked.
+type gitDataChange func(task db.Task, data *domain.GitData) (string, error)
+
+// updateGitData locks the task, applies change and stores the result if the
+// git data is still valid. Status changes requested by change only happen
+// when the workflow and the task's blockers allow them, since they are
+// automatic.
+func (s *TaskService) updateGitData(ctx context.Context, id pgt.UUID, change gitDataChange) (db.Task, error) {
+	if err := requireMember(ctx, "changing a task's git data"); err != nil {
+		return db.Task{}, err
+	}
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		current, err := s.lockTask(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		encoded, status, err := changeGitData(s.workflow, current, change)
+		if err != nil {
+			return err
+		}
+
+		arg := db.UpdateTaskParams{ID: id, GitData: encoded}
+		if status != "" {
+			if err := checkBlockers(ctx, q, id, status); err != nil {
+				fmt.Printf("TaskService: Not moving task %v to %s: %v\n", id, status, err)
+			} else {
+				arg.Status = pgt.Text{String: status, Valid: true}
+			}
+		}
+		task, err = q.UpdateTask(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if err := recordAudit(ctx, q, AuditEntityTask, id, AuditActionUpdate, taskAuditFields(current), taskAuditFields(task)); err != nil {
+			return err
+		}
+		if !arg.Status.Valid {
+			return nil
+		}
+		return s.deriveFeatureStatus(ctx, q, task.FeatureID)
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// changeGitData applies change to the task's git data and returns the result
+// encoded, with the status change asked for if the workflow allows the move
+// and "" otherwise.
+func changeGitData(workflow domain.Workflow, task db.Task, change gitDataChange) ([]byte, string, error) {
+	data := domain.ParseStoredGitData(task.GitData)
+	status, err := change(task, &data)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := data.Validate(); err != nil {
+		return nil, "", fmt.Errorf("%w: invalid git_data: %v", ErrValidation, err)
+	}
+	encoded, err := encodeGitData(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if status == "" || status == task.Status.String {
+		return encoded, "", nil
+	}
+	if err := checkStatusChange(workflow, task.Status, status); err != nil {
+		fmt.Printf("TaskService: Not moving task %v to %s: %v\n", task.ID, status, err)
+		return encoded, "", nil
+	}
+	return encoded, status, nil
+}
+
+// startedStatus is the status a task moves to once work on it shows up in
+// git: in progress if it hasn't been started yet, so a pull request opened
+// next can move it on to review.
+func startedStatus(workflow domain.Workflow, task db.Task) string {
+	if task.Status.String == workflow.InitialStatus {
+		return inProgressStatus
+	}
+	return ""
+}
+
+// AddTaskBranch links a branch, and its repo if needed, to the task.
+func (s *TaskService) AddTaskBranch(ctx context.Context, id pgt.UUID, branch domain.GitBranch) (db.Task, error) {
+	return s.updateGitData(ctx, id, func(_ db.Task, data *domain.GitData) (string, error) {
+		if err := data.AddBranch(branch); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		return "", nil
+	})
+}
+
+// RemoveTaskBranch unlinks a branch from the task.
+func (s *TaskService) RemoveTaskBranch(ctx context.Context, id pgt.UUID, repo, name string) (db.Task, error) {
+	return s.updateGitData(ctx, id, func(_ db.Task, data *domain.GitData) (string, error) {
+		if !data.RemoveBranch(repo, name) {
+			return "", fmt.Errorf("branch %q of repo %q is not linked: %w", name, repo, ErrNotFound)
+		}
+		return "", nil
+	})
+}
+
+// AddTaskCommit records a commit, and the branch it was made on, for the
+// task, which moves to in progress if it hasn't been started yet.
+func (s *TaskService) AddTaskCommit(ctx context.Context, id pgt.UUID, commit domain.GitCommit) (db.Task, error) {
+	return s.updateGitData(ctx, id, addCommit(s.workflow, commit))
+}
+
+func addCommit(workflow domain.Workflow, commit domain.GitCommit) gitDataChange {
+	return func(task db.Task, data *domain.GitData) (string, error) {
+		if commit.Branch != "" {
+			if err := data.AddBranch(domain.GitBranch{Repo: commit.Repo, Name: commit.Branch}); err != nil {
+				return "", fmt.Errorf("%w: %v", ErrValidation, err)
+			}
+		}
+		if err := data.AddCommit(commit); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		return startedStatus(workflow, task), nil
+	}
+}
+
+// AddTaskPullRequest links a pull request, and its repo if needed, to the
+// task. A pull request that is already linked is updated. Linking an open
+// pull request moves the task to review, and a merged one moves it to done.
+func (s *TaskService) AddTaskPullRequest(ctx context.Context, id pgt.UUID, pr domain.GitPullRequest) (db.Task, error) {
+	return s.updateGitData(ctx, id, linkPullRequest(pr))
+}
+
+func linkPullRequest(pr domain.GitPullRequest) gitDataChange {
+	return func(_ db.Task, data *domain.GitData) (string, error) {
+		if err := data.UpsertPullRequest(pr); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		return pullRequestStatus(pr.State), nil
+	}
+}
+
+// RemoveTaskPullRequest unlinks a pull request from the task.
+func (s *TaskService) RemoveTaskPullRequest(ctx context.Context, id pgt.UUID, repo string, number int) (db.Task, error) {
+	return s.updateGitData(ctx, id, func(_ db.Task, data *domain.GitData) (string, error) {
+		if !data.RemovePullRequest(repo, number) {
+			return "", fmt.Errorf("pull request %s#%d is not linked: %w", repo, number, ErrNotFound)
+		}
+		return "", nil
+	})
+}
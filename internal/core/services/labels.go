@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// maxLabelNameLength keeps label names short enough to show as chips.
+const maxLabelNameLength = 50
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// LabelService manages user-defined labels, such as "bug" or "frontend", and
+// attaches them to tasks and features.
+type LabelService struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+}
+
+func NewLabelService(queries *db.Queries, pool *pgxpool.Pool) *LabelService {
+	return &LabelService{queries: queries, pool: pool}
+}
+
+func (s *LabelService) ListLabels(ctx context.Context) ([]db.Label, error) {
+	labels, err := s.queries.ListLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	return labels, nil
+}
+
+// CreateLabel creates a label. Names are unique regardless of case and
+// colors are "#rrggbb" hex codes.
+func (s *LabelService) CreateLabel(ctx context.Context, arg db.CreateLabelParams) (db.Label, error) {
+	if err := requireMember(ctx, "creating labels"); err != nil {
+		return db.Label{}, err
+	}
+	var err error
+	if arg.Name, err = normalizeLabelName(arg.Name); err != nil {
+		return db.Label{}, err
+	}
+	if arg.Color, err = normalizeLabelColor(arg.Color); err != nil {
+		return db.Label{}, err
+	}
+	arg.CreatedBy = actorFromContext(ctx)
+
+	label, err := s.queries.CreateLabel(ctx, arg)
+	if isUniqueViolation(err) {
+		return db.Label{}, fmt.Errorf("%w: a label named %q already exists", ErrConflict, arg.Name)
+	}
+	if err != nil {
+		return db.Label{}, fmt.Errorf("failed to create label: %w", err)
+	}
+	return label, nil
+}
+
+// UpdateLabel renames or recolors a label everywhere it is attached.
+func (s *LabelService) UpdateLabel(ctx context.Context, arg db.UpdateLabelParams) (db.Label, error) {
+	if err := requireMember(ctx, "updating labels"); err != nil {
+		return db.Label{}, err
+	}
+	if arg.Name.Valid {
+		name, err := normalizeLabelName(arg.Name.String)
+		if err != nil {
+			return db.Label{}, err
+		}
+		arg.Name.String = name
+	}
+	if arg.Color.Valid {
+		color, err := normalizeLabelColor(arg.Color.String)
+		if err != nil {
+			return db.Label{}, err
+		}
+		arg.Color.String = color
+	}
+
+	label, err := s.queries.UpdateLabel(ctx, arg)
+	if isUniqueViolation(err) {
+		return db.Label{}, fmt.Errorf("%w: a label named %q already exists", ErrConflict, arg.Name.String)
+	}
+	if err != nil {
+		return db.Label{}, fmt.Errorf("failed to update label: %w", notFound(err))
+	}
+	return label, nil
+}
+
+// DeleteLabel deletes a label and detaches it from every task and feature.
+// Only admins may delete labels, since others may rely on them.
+func (s *LabelService) DeleteLabel(ctx context.Context, id pgt.UUID) error {
+	if err := requireAdmin(ctx, "deleting labels"); err != nil {
+		return err
+	}
+	n, err := s.queries.DeleteLabel(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete label: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// AttachTaskLabel labels the task and returns the task's labels. Attaching a
+// label twice is a no-op.
+func (s *LabelService) AttachTaskLabel(ctx context.Context, taskID, labelID pgt.UUID) ([]db.Label, error) {
+	if err := requireMember(ctx, "labelling tasks"); err != nil {
+		return nil, err
+	}
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if _, err := q.GetTaskForUpdate(ctx, taskID); err != nil {
+			return fmt.Errorf("failed to get task: %w", notFound(err))
+		}
+		label, err := q.GetLabel(ctx, labelID)
+		if err != nil {
+			return fmt.Errorf("failed to get label: %w", notFound(err))
+		}
+		n, err := q.AddTaskLabel(ctx, db.AddTaskLabelParams{TaskID: taskID, LabelID: labelID})
+		if err != nil {
+			return fmt.Errorf("failed to label task: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		return recordAudit(ctx, q, AuditEntityTask, taskID, AuditActionLabelAdded, nil, map[string]any{"label": label.Name})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.taskLabels(ctx, taskID)
+}
+
+// DetachTaskLabel removes the label from the task and returns the task's
+// remaining labels.
+func (s *LabelService) DetachTaskLabel(ctx context.Context, taskID, labelID pgt.UUID) ([]db.Label, error) {
+	if err := requireMember(ctx, "labelling tasks"); err != nil {
+		return nil, err
+	}
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		label, err := q.GetLabel(ctx, labelID)
+		if err != nil {
+			return fmt.Errorf("failed to get label: %w", notFound(err))
+		}
+		n, err := q.RemoveTaskLabel(ctx, db.RemoveTaskLabelParams{TaskID: taskID, LabelID: labelID})
+		if err != nil {
+			return fmt.Errorf("failed to unlabel task: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: the task isn't labelled %q", ErrNotFound, label.Name)
+		}
+		return recordAudit(ctx, q, AuditEntityTask, taskID, AuditActionLabelRemoved, map[string]any{"label": label.Name}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.taskLabels(ctx, taskID)
+}
+
+// AttachFeatureLabel labels the feature and returns the feature's labels.
+// Attaching a label twice is a no-op. Only the feature's owners and admins
+// may label it.
+func (s *LabelService) AttachFeatureLabel(ctx context.Context, featureID, labelID pgt.UUID) ([]db.Label, error) {
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if _, err := q.GetFeatureForUpdate(ctx, featureID); err != nil {
+			return fmt.Errorf("failed to get feature: %w", notFound(err))
+		}
+		if err := requireFeatureOwner(ctx, q, featureID, "labelling the feature"); err != nil {
+			return err
+		}
+		label, err := q.GetLabel(ctx, labelID)
+		if err != nil {
+			return fmt.Errorf("failed to get label: %w", notFound(err))
+		}
+		n, err := q.AddFeatureLabel(ctx, db.AddFeatureLabelParams{FeatureID: featureID, LabelID: labelID})
+		if err != nil {
+			return fmt.Errorf("failed to label feature: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, featureID, AuditActionLabelAdded, nil, map[string]any{"label": label.Name})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.featureLabels(ctx, featureID)
+}
+
+// DetachFeatureLabel removes the label from the feature and returns the
+// feature's remaining labels. Only the feature's owners and admins may
+// unlabel it.
+func (s *LabelService) DetachFeatureLabel(ctx context.Context, featureID, labelID pgt.UUID) ([]db.Label, error) {
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if err := requireFeatureOwner(ctx, q, featureID, "labelling the feature"); err != nil {
+			return err
+		}
+		label, err := q.GetLabel(ctx, labelID)
+		if err != nil {
+			return fmt.Errorf("failed to get label: %w", notFound(err))
+		}
+		n, err := q.RemoveFeatureLabel(ctx, db.RemoveFeatureLabelParams{FeatureID: featureID, LabelID: labelID})
+		if err != nil {
+			return fmt.Errorf("failed to unlabel feature: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: the feature isn't labelled %q", ErrNotFound, label.Name)
+		}
+		return recordAudit(ctx, q, AuditEntityFeature, featureID, AuditActionLabelRemoved, map[string]any{"label": label.Name}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.featureLabels(ctx, featureID)
+}
+
+func (s *LabelService) taskLabels(ctx context.Context, taskID pgt.UUID) ([]db.Label, error) {
+	byTask, err := listLabelsByTask(ctx, s.queries, []pgt.UUID{taskID})
+	if err != nil {
+		return nil, err
+	}
+	return byTask[taskID], nil
+}
+
+func (s *LabelService) featureLabels(ctx context.Context, featureID pgt.UUID) ([]db.Label, error) {
+	byFeature, err := listLabelsByFeature(ctx, s.queries, []pgt.UUID{featureID})
+	if err != nil {
+		return nil, err
+	}
+	return byFeature[featureID], nil
+}
+
+// listLabelsByTask loads the labels of several tasks with one query and
+// groups them by task ID, sorted by name.
+func listLabelsByTask(ctx context.Context, q *db.Queries, taskIDs []pgt.UUID) (map[pgt.UUID][]db.Label, error) {
+	rows, err := q.ListLabelsByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task labels: %w", err)
+	}
+	byTask := make(map[pgt.UUID][]db.Label, len(taskIDs))
+	for _, row := range rows {
+		byTask[row.TaskID] = append(byTask[row.TaskID], row.Label)
+	}
+	return byTask, nil
+}
+
+// listLabelsByFeature loads the labels of several features with one query
+// and groups them by feature ID, sorted by name.
+func listLabelsByFeature(ctx context.Context, q *db.Queries, featureIDs []pgt.UUID) (map[pgt.UUID][]db.Label, error) {
+	rows, err := q.ListLabelsByFeatureIDs(ctx, featureIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature labels: %w", err)
+	}
+	byFeature := make(map[pgt.UUID][]db.Label, len(featureIDs))
+	for _, row := range rows {
+		byFeature[row.FeatureID] = append(byFeature[row.FeatureID], row.Label)
+	}
+	return byFeature, nil
+}
+
+func normalizeLabelName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxLabelNameLength {
+		return "", fmt.Errorf("%w: label name must be 1 to %d characters", ErrValidation, maxLabelNameLength)
+	}
+	return name, nil
+}
+
+func normalizeLabelColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if !labelColorPattern.MatchString(color) {
+		return "", fmt.Errorf("%w: label color %q must look like #1f6feb", ErrValidation, color)
+	}
+	return color, nil
+}
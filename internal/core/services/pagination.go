@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/ports"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// sortColumns lists the columns list endpoints can be sorted by. Time columns
+// are compared as timestamps, everything else as text, so priority and status
+// sort alphabetically rather than in the workflow's order.
+var sortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"name":       false,
+	"priority":   false,
+	"status":     false,
+}
+
+// pageCursor is the decoded form of the opaque next_cursor. It remembers the
+// sort it was issued for so it can't be replayed against a different order.
+type pageCursor struct {
+	Sort  string     `json:"sort"`
+	Order string     `json:"order"`
+	Time  *time.Time `json:"t,omitempty"`
+	Text  *string    `json:"s,omitempty"`
+	ID    uuid.UUID  `json:"id"`
+}
+
+// listPage is a validated PageRequest, ready to be copied into the sqlc
+// parameters of a list query.
+type listPage struct {
+	sortBy     string
+	order      string
+	limit      int
+	cursorTime pgt.Timestamptz
+	cursorText pgt.Text
+	cursorID   pgt.UUID
+}
+
+func (p listPage) descending() bool {
+	return p.order == "desc"
+}
+
+// queryLimit fetches one row more than the page size so we can tell whether
+// another page follows without a separate count query.
+func (p listPage) queryLimit() int32 {
+	return int32(p.limit + 1)
+}
+
+func parsePage(page ports.PageRequest) (listPage, error) {
+	p := listPage{sortBy: page.Sort, order: page.Order, limit: page.Limit}
+	if p.sortBy == "" {
+		p.sortBy = "created_at"
+	}
+	if _, ok := sortColumns[p.sortBy]; !ok {
+		return listPage{}, fmt.Errorf("%w: unknown sort column %q", ErrInvalidInput, p.sortBy)
+	}
+	if p.order == "" {
+		p.order = "desc"
+	}
+	if p.order != "asc" && p.order != "desc" {
+		return listPage{}, fmt.Errorf("%w: order must be asc or desc", ErrInvalidInput)
+	}
+	if p.limit <= 0 {
+		p.limit = defaultPageSize
+	}
+	if p.limit > maxPageSize {
+		p.limit = maxPageSize
+	}
+
+	if page.Cursor == "" {
+		return p, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return listPage{}, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return listPage{}, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	if c.Sort != p.sortBy || c.Order != p.order {
+		return listPage{}, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidInput)
+	}
+	if c.Time != nil {
+		p.cursorTime = pgt.Timestamptz{Time: *c.Time, Valid: true}
+	}
+	if c.Text != nil {
+		p.cursorText = pgt.Text{String: *c.Text, Valid: true}
+	}
+	p.cursorID = pgt.UUID{Bytes: c.ID, Valid: true}
+	return p, nil
+}
+
+// nextCursor encodes the position after the last row of the page. Only the
+// value matching the sort column is kept.
+func (p listPage) nextCursor(id pgt.UUID, sortTime pgt.Timestamptz, sortText pgt.Text) string {
+	c := pageCursor{Sort: p.sortBy, Order: p.order, ID: id.Bytes}
+	if sortColumns[p.sortBy] {
+		c.Time = &sortTime.Time
+	} else {
+		// NULL text values sort as the empty string, see the list queries.
+		c.Text = &sortText.String
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// sortValues picks the value of the sort column out of a row's columns.
+func (p listPage) sortValues(createdAt, updatedAt pgt.Timestamptz, name string, priority, status pgt.Text) (pgt.Timestamptz, pgt.Text) {
+	switch p.sortBy {
+	case "created_at":
+		return createdAt, pgt.Text{}
+	case "updated_at":
+		return updatedAt, pgt.Text{}
+	case "name":
+		return pgt.Timestamptz{}, pgt.Text{String: name, Valid: true}
+	case "priority":
+		return pgt.Timestamptz{}, priority
+	default:
+		return pgt.Timestamptz{}, status
+	}
+}
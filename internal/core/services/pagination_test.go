@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/ports"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	createdAt := pgt.Timestamptz{Time: time.Date(2026, 10, 1, 9, 30, 0, 123456000, time.UTC), Valid: true}
+	updatedAt := pgt.Timestamptz{Time: time.Date(2026, 10, 2, 17, 0, 0, 0, time.UTC), Valid: true}
+	high := pgt.Text{String: "high", Valid: true}
+	status := pgt.Text{String: "in_progress", Valid: true}
+	id := pgt.UUID{Bytes: uuid.MustParse("33333333-3333-3333-3333-333333333333"), Valid: true}
+
+	tests := []struct {
+		sort     string
+		order    string
+		priority pgt.Text
+		wantTime pgt.Timestamptz
+		wantText pgt.Text
+	}{
+		{"", "", high, createdAt, pgt.Text{}},
+		{"created_at", "asc", high, createdAt, pgt.Text{}},
+		{"updated_at", "desc", high, updatedAt, pgt.Text{}},
+		{"name", "asc", high, pgt.Timestamptz{}, pgt.Text{String: "Login page", Valid: true}},
+		{"priority", "desc", high, pgt.Timestamptz{}, high},
+		{"status", "asc", high, pgt.Timestamptz{}, status},
+		// NULL text values continue from the empty string.
+		{"priority", "asc", pgt.Text{}, pgt.Timestamptz{}, pgt.Text{String: "", Valid: true}},
+	}
+	for _, tt := range tests {
+		page := ports.PageRequest{Sort: tt.sort, Order: tt.order, Limit: 10}
+		first, err := parsePage(page)
+		if err != nil {
+			t.Fatalf("parsePage(%+v) = %v", page, err)
+		}
+		sortTime, sortText := first.sortValues(createdAt, updatedAt, "Login page", tt.priority, status)
+
+		page.Cursor = first.nextCursor(id, sortTime, sortText)
+		next, err := parsePage(page)
+		if err != nil {
+			t.Fatalf("parsePage with cursor for sort %q = %v", tt.sort, err)
+		}
+		if next.sortBy != first.sortBy || next.order != first.order || next.limit != 10 {
+			t.Errorf("sort %q: got sort %q order %q limit %d, want %q %q 10", tt.sort, next.sortBy, next.order, next.limit, first.sortBy, first.order)
+		}
+		if next.cursorTime.Valid != tt.wantTime.Valid || !next.cursorTime.Time.Equal(tt.wantTime.Time) {
+			t.Errorf("sort %q: cursor time = %v, want %v", tt.sort, next.cursorTime, tt.wantTime)
+		}
+		if next.cursorText != tt.wantText {
+			t.Errorf("sort %q: cursor text = %+v, want %+v", tt.sort, next.cursorText, tt.wantText)
+		}
+		if next.cursorID != id {
+			t.Errorf("sort %q: cursor id = %v, want %v", tt.sort, next.cursorID, id)
+		}
+	}
+}
+
+func TestParsePageLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, defaultPageSize},
+		{-5, defaultPageSize},
+		{1, 1},
+		{maxPageSize, maxPageSize},
+		{maxPageSize + 1, maxPageSize},
+	}
+	for _, tt := range tests {
+		p, err := parsePage(ports.PageRequest{Limit: tt.limit})
+		if err != nil {
+			t.Fatalf("parsePage(limit %d) = %v", tt.limit, err)
+		}
+		if p.limit != tt.want {
+			t.Errorf("parsePage(limit %d).limit = %d, want %d", tt.limit, p.limit, tt.want)
+		}
+		if got := p.queryLimit(); got != int32(tt.want+1) {
+			t.Errorf("parsePage(limit %d).queryLimit() = %d, want %d", tt.limit, got, tt.want+1)
+		}
+	}
+}
+
+func TestParsePageRejects(t *testing.T) {
+	byName, _ := parsePage(ports.PageRequest{Sort: "name", Order: "asc"})
+	cursor := byName.nextCursor(pgt.UUID{Valid: true}, pgt.Timestamptz{}, pgt.Text{String: "Login page", Valid: true})
+
+	tests := []struct {
+		name string
+		page ports.PageRequest
+	}{
+		{"unknown sort", ports.PageRequest{Sort: "assignee"}},
+		{"invalid order", ports.PageRequest{Order: "up"}},
+		{"not base64", ports.PageRequest{Sort: "name", Order: "asc", Cursor: "not a cursor!"}},
+		{"tampered cursor", ports.PageRequest{Sort: "name", Order: "asc", Cursor: cursor[:len(cursor)-4]}},
+		{"cursor from another sort", ports.PageRequest{Sort: "status", Order: "asc", Cursor: cursor}},
+		{"cursor from another order", ports.PageRequest{Sort: "name", Order: "desc", Cursor: cursor}},
+		{"cursor from the default sort", ports.PageRequest{Cursor: cursor}},
+	}
+	for _, tt := range tests {
+		if _, err := parsePage(tt.page); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: parsePage = %v, want ErrInvalidInput", tt.name, err)
+		}
+	}
+}
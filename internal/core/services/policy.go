@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// Roles a user may have, from users.role. Viewers can only read, members can
+// also change tasks and the features they own, and admins can do everything.
+// Users with a role not listed here get viewer rights. RoleAgent is held by
+// the AgentUserID user only and can't be given to anyone else.
+const (
+	RoleViewer = "viewer"
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+	RoleAgent  = "agent"
+)
+
+var roles = []string{RoleViewer, RoleMember, RoleAdmin}
+
+// The checks below run in the services so every adapter enforces the same
+// rules. A context without a user is the system itself, such as the agent
+// workers, signed webhooks or the command line, and may do anything.
+
+// checkRole validates a role given for a user.
+func checkRole(role string) error {
+	if !slices.Contains(roles, role) {
+		return fmt.Errorf("%w: role %q must be one of %v", ErrValidation, role, roles)
+	}
+	return nil
+}
+
+// requireMember allows members and admins. action describes what is being
+// attempted, e.g. "updating tasks", for the denial reason.
+func requireMember(ctx context.Context, action string) error {
+	user, ok := UserFromContext(ctx)
+	if !ok || user.Role == RoleMember || user.Role == RoleAdmin {
+		return nil
+	}
+	return &PermissionError{Reason: fmt.Sprintf("%s needs the member or admin role, yours is %q", action, user.Role)}
+}
+
+// requireAdmin allows admins only.
+func requireAdmin(ctx context.Context, action string) error {
+	user, ok := UserFromContext(ctx)
+	if !ok || user.Role == RoleAdmin {
+		return nil
+	}
+	return &PermissionError{Reason: fmt.Sprintf("%s needs the admin role, yours is %q", action, user.Role)}
+}
+
+// requireFeatureOwner allows admins and members who own the feature.
+func requireFeatureOwner(ctx context.Context, q *db.Queries, featureID pgt.UUID, action string) error {
+	user, ok := UserFromContext(ctx)
+	if !ok || user.Role == RoleAdmin {
+		return nil
+	}
+	if err := requireMember(ctx, action); err != nil {
+		return err
+	}
+	owner, err := q.IsFeatureOwner(ctx, db.IsFeatureOwnerParams{FeatureID: featureID, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("failed to check feature ownership: %w", err)
+	}
+	if !owner {
+		return &PermissionError{Reason: fmt.Sprintf("%s is limited to the feature's owners and admins", action)}
+	}
+	return nil
+}
+
+// requireAuthor allows the author of a comment or work log and admins.
+// Comments without an author, such as system comments or those whose author
+// was deleted, are left to admins. The agent's comments are authored by
+// AgentUserID, so only admins and the agent's own runs, acting as the system,
+// may change them.
+func requireAuthor(ctx context.Context, authorID pgt.UUID, action string) error {
+	user, ok := UserFromContext(ctx)
+	if !ok || user.Role == RoleAdmin {
+		return nil
+	}
+	if authorID.Valid && authorID == user.ID {
+		return nil
+	}
+	return &PermissionError{Reason: fmt.Sprintf("%s is limited to its author and admins", action)}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+var (
+	authorID = pgt.UUID{Bytes: uuid.MustParse("11111111-1111-1111-1111-111111111111"), Valid: true}
+	otherID  = pgt.UUID{Bytes: uuid.MustParse("22222222-2222-2222-2222-222222222222"), Valid: true}
+)
+
+// policyContexts are the callers every policy check is tested against. The
+// viewer, member and admin are signed in as authorID, the agent as
+// AgentUserID, and the system has no user at all.
+func policyContexts() map[string]context.Context {
+	ctx := context.Background()
+	return map[string]context.Context{
+		"viewer": ContextWithUser(ctx, db.User{ID: authorID, Role: RoleViewer}),
+		"member": ContextWithUser(ctx, db.User{ID: authorID, Role: RoleMember}),
+		"admin":  ContextWithUser(ctx, db.User{ID: authorID, Role: RoleAdmin}),
+		"agent":  ContextWithUser(ctx, db.User{ID: AgentUserID, Role: RoleAgent}),
+		"system": ctx,
+	}
+}
+
+func TestCheckRole(t *testing.T) {
+	tests := []struct {
+		role    string
+		wantErr bool
+	}{
+		{RoleViewer, false},
+		{RoleMember, false},
+		{RoleAdmin, false},
+		{RoleAgent, true},
+		{"owner", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		err := checkRole(tt.role)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkRole(%q) = %v, want error %v", tt.role, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrValidation) {
+			t.Errorf("checkRole(%q) = %v, want ErrValidation", tt.role, err)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   func(ctx context.Context) error
+		allowed []string
+	}{
+		{
+			name:    "requireMember",
+			check:   func(ctx context.Context) error { return requireMember(ctx, "updating tasks") },
+			allowed: []string{"member", "admin", "system"},
+		},
+		{
+			name:    "requireAdmin",
+			check:   func(ctx context.Context) error { return requireAdmin(ctx, "deleting users") },
+			allowed: []string{"admin", "system"},
+		},
+		{
+			name:    "requireAuthor of own comment",
+			check:   func(ctx context.Context) error { return requireAuthor(ctx, authorID, "editing the comment") },
+			allowed: []string{"viewer", "member", "admin", "system"},
+		},
+		{
+			name:    "requireAuthor of someone else's comment",
+			check:   func(ctx context.Context) error { return requireAuthor(ctx, otherID, "editing the comment") },
+			allowed: []string{"admin", "system"},
+		},
+		{
+			name:    "requireAuthor of the agent's comment",
+			check:   func(ctx context.Context) error { return requireAuthor(ctx, AgentUserID, "editing the comment") },
+			allowed: []string{"agent", "admin", "system"},
+		},
+		{
+			name:    "requireAuthor of a comment without author",
+			check:   func(ctx context.Context) error { return requireAuthor(ctx, pgt.UUID{}, "editing the comment") },
+			allowed: []string{"admin", "system"},
+		},
+	}
+	for _, tt := range tests {
+		for caller, ctx := range policyContexts() {
+			err := tt.check(ctx)
+			allowed := slices.Contains(tt.allowed, caller)
+			if allowed && err != nil {
+				t.Errorf("%s as %s = %v, want allowed", tt.name, caller, err)
+			}
+			if !allowed {
+				var perr *PermissionError
+				if !errors.As(err, &perr) || !errors.Is(err, ErrForbidden) {
+					t.Errorf("%s as %s = %v, want a PermissionError", tt.name, caller, err)
+				}
+			}
+		}
+	}
+}
+
+// TestRequireFeatureOwnerWithoutQuery covers the callers decided by their role
+// alone, before the feature's owners are looked up.
+func TestRequireFeatureOwnerWithoutQuery(t *testing.T) {
+	contexts := policyContexts()
+	tests := []struct {
+		caller  string
+		wantErr bool
+	}{
+		{"admin", false},
+		{"system", false},
+		{"viewer", true},
+		{"agent", true},
+	}
+	for _, tt := range tests {
+		err := requireFeatureOwner(contexts[tt.caller], nil, otherID, "updating the feature")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("requireFeatureOwner as %s = %v, want error %v", tt.caller, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrForbidden) {
+			t.Errorf("requireFeatureOwner as %s = %v, want ErrForbidden", tt.caller, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
+)
+
+// PullRequestService opens pull requests for task branches and keeps the
+// pull requests linked in the tasks' git_data in sync with the provider.
+type PullRequestService struct {
+	tasks    gitDataTasks
+	queries  *db.Queries
+	workflow domain.Workflow
+	provider ports.PullRequestProvider
+}
+
+// gitDataTasks is the part of TaskService that PullRequestService changes
+// tasks through, so it can be tested without a database.
+type gitDataTasks interface {
+	GetTask(ctx context.Context, id pgt.UUID) (db.Task, error)
+	updateGitData(ctx context.Context, id pgt.UUID, change gitDataChange) (db.Task, error)
+}
+
+func NewPullRequestService(tasks *TaskService, provider ports.PullRequestProvider) *PullRequestService {
+	return &PullRequestService{tasks: tasks, queries: tasks.queries, workflow: tasks.workflow, provider: provider}
+}
+
+// OpenPullRequest opens a pull request from branch into the repo's default
+// branch and links it to the task, which moves the task to review. A pull
+// request that is already open for the branch is reused.
+func (s *PullRequestService) OpenPullRequest(ctx context.Context, task db.Task, repo domain.GitRepo, branch string) (domain.GitPullRequest, error) {
+	pr, err := s.provider.CreatePullRequest(ctx, repo, ports.NewPullRequest{
+		Title: task.Name,
+		Body:  pullRequestBody(task),
+		Head:  branch,
+		Base:  repo.DefaultBranch,
+	})
+	if err != nil {
+		return domain.GitPullRequest{}, fmt.Errorf("failed to open pull request on %s: %w", repo.FullName(), err)
+	}
+	linked := domain.GitPullRequest{
+		Repo:   repo.FullName(),
+		Number: pr.Number,
+		URL:    pr.URL,
+		State:  pr.State,
+		Branch: branch,
+	}
+	if _, err := s.tasks.updateGitData(ctx, task.ID, linkPullRequest(linked)); err != nil {
+		return domain.GitPullRequest{}, fmt.Errorf("failed to link pull request %s#%d: %w", linked.Repo, linked.Number, err)
+	}
+	return linked, nil
+}
+
+// RefreshPullRequest fetches the state of a linked pull request from the
+// provider and stores it in the task's git_data.
+func (s *PullRequestService) RefreshPullRequest(ctx context.Context, taskID pgt.UUID, repo string, number int) (db.Task, error) {
+	linked, gitRepo, err := s.linkedPullRequest(ctx, taskID, repo, number)
+	if err != nil {
+		return db.Task{}, err
+	}
+	pr, err := s.provider.GetPullRequest(ctx, gitRepo, number)
+	if err != nil {
+		return db.Task{}, fmt.Errorf("%w: failed to fetch pull request: %v", ErrUpstream, err)
+	}
+	linked.State = pr.State
+	linked.URL = pr.URL
+	if pr.Head != "" {
+		linked.Branch = pr.Head
+	}
+	return s.tasks.updateGitData(ctx, taskID, linkPullRequest(linked))
+}
+
+// ListReviewComments returns the review comments of a pull request linked
+// to the task.
+func (s *PullRequestService) ListReviewComments(ctx context.Context, taskID pgt.UUID, repo string, number int) ([]ports.ReviewComment, error) {
+	_, gitRepo, err := s.linkedPullRequest(ctx, taskID, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.provider.ListReviewComments(ctx, gitRepo, number)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list review comments: %v", ErrUpstream, err)
+	}
+	return comments, nil
+}
+
+// linkedPullRequest finds a pull request in the task's git_data, so only
+// pull requests that belong to the task can be inspected through it.
+func (s *PullRequestService) linkedPullRequest(ctx context.Context, taskID pgt.UUID, repo string, number int) (domain.GitPullRequest, domain.GitRepo, error) {
+	task, err := s.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		return domain.GitPullRequest{}, domain.GitRepo{}, err
+	}
+	data := domain.ParseStoredGitData(task.GitData)
+	gitRepo, _ := data.Repo(repo)
+	for _, pr := range data.PullRequests {
+		if pr.Number == number && strings.EqualFold(pr.Repo, repo) {
+			return pr, gitRepo, nil
+		}
+	}
+	return domain.GitPullRequest{}, domain.GitRepo{}, fmt.Errorf("pull request %s#%d is not linked: %w", repo, number, ErrNotFound)
+}
+
+// SyncPullRequest applies a pull request event from the code host to the
+// tasks it refers to: their git_data gets the pull request's current state,
+// and opening it moves them to review, merging it to done and requesting
+// changes back to in progress. It returns the updated tasks.
+func (s *PullRequestService) SyncPullRequest(ctx context.Context, event ports.PullRequestEvent) ([]db.Task, error) {
+	pr := event.PullRequest
+	ids, err := s.matchTasks(ctx, event.Repo, pr.Head, pr.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	status := ""
+	switch {
+	case event.ReviewState == ports.ReviewChangesRequested:
+		status = inProgressStatus
+	case event.ReviewState == "" && slices.Contains([]string{"opened", "reopened", "ready_for_review", "closed"}, event.Action):
+		status = pullRequestStatus(pr.State)
+	}
+	linked := domain.GitPullRequest{
+		Repo:   event.Repo.FullName(),
+		Number: pr.Number,
+		URL:    pr.URL,
+		State:  pr.State,
+		Branch: pr.Head,
+	}
+	return s.syncTasks(ctx, ids, func(_ db.Task, data *domain.GitData) (string, error) {
+		data.LinkRepo(event.Repo)
+		if err := data.UpsertPullRequest(linked); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		return status, nil
+	})
+}
+
+// SyncPush applies a push from the code host to the tasks that work on the
+// branch: the commits are recorded, and tasks that haven't been started yet
+// move to in progress. Deleting the branch unlinks it.
+func (s *PullRequestService) SyncPush(ctx context.Context, event ports.PushEvent) ([]db.Task, error) {
+	ids, err := s.matchTasks(ctx, event.Repo, event.Branch, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.syncTasks(ctx, ids, func(task db.Task, data *domain.GitData) (string, error) {
+		if event.Deleted {
+			data.RemoveBranch(event.Repo.FullName(), event.Branch)
+			return "", nil
+		}
+		data.LinkRepo(event.Repo)
+		if err := data.AddBranch(domain.GitBranch{Repo: event.Repo.FullName(), Name: event.Branch}); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		for _, commit := range event.Commits {
+			if err := data.AddCommit(commit); err != nil {
+				return "", fmt.Errorf("%w: %v", ErrValidation, err)
+			}
+		}
+		if len(event.Commits) == 0 {
+			return "", nil
+		}
+		return startedStatus(s.workflow, task), nil
+	})
+}
+
+// matchTasks finds the tasks an event refers to: the one a task/<id> branch
+// was named after, those mentioned by ID in the pull request title, and
+// those that already link the branch.
+func (s *PullRequestService) matchTasks(ctx context.Context, repo domain.GitRepo, branch, title string) ([]pgt.UUID, error) {
+	keys := domain.TaskKeys(title)
+	if id, ok := domain.TaskIDFromBranch(branch); ok {
+		keys = append(keys, id)
+	}
+	var candidates []pgt.UUID
+	for _, key := range keys {
+		if id, err := uuid.Parse(key); err == nil {
+			candidates = append(candidates, pgt.UUID{Bytes: id, Valid: true})
+		}
+	}
+	if branch != "" {
+		linked, err := s.queries.ListTaskIDsByGitBranch(ctx, db.ListTaskIDsByGitBranchParams{Repo: repo.FullName(), Branch: branch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tasks for branch %s: %w", branch, err)
+		}
+		candidates = append(candidates, linked...)
+	}
+
+	var ids []pgt.UUID
+	seen := make(map[[16]byte]bool)
+	for _, id := range candidates {
+		if !seen[id.Bytes] {
+			seen[id.Bytes] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// syncTasks applies change to each task. Tasks that no longer exist are
+// skipped, since an event may mention any ID.
+func (s *PullRequestService) syncTasks(ctx context.Context, ids []pgt.UUID, change gitDataChange) ([]db.Task, error) {
+	tasks := []db.Task{}
+	for _, id := range ids {
+		task, err := s.tasks.updateGitData(ctx, id, change)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return tasks, fmt.Errorf("failed to sync task %s: %w", uuid.UUID(id.Bytes), err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func pullRequestBody(task db.Task) string {
+	body := fmt.Sprintf("Task: %s\n", uuid.UUID(task.ID.Bytes))
+	if task.Description.Valid && task.Description.String != "" {
+		body += "\n" + task.Description.String + "\n"
+	}
+	return body + "\nOpened by the coding agent.\n"
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/adapters/github"
+	"shelke.dev/api/internal/core/domain"
+)
+
+// memoryTasks keeps tasks in memory and changes their git data the way
+// TaskService does, without blockers, audit events or a database.
+type memoryTasks struct {
+	workflow domain.Workflow
+	tasks    map[pgt.UUID]db.Task
+}
+
+func newMemoryTasks(workflow domain.Workflow, tasks ...db.Task) *memoryTasks {
+	m := &memoryTasks{workflow: workflow, tasks: make(map[pgt.UUID]db.Task)}
+	for _, task := range tasks {
+		m.tasks[task.ID] = task
+	}
+	return m
+}
+
+func (m *memoryTasks) GetTask(ctx context.Context, id pgt.UUID) (db.Task, error) {
+	task, ok := m.tasks[id]
+	if !ok {
+		return db.Task{}, ErrNotFound
+	}
+	return task, nil
+}
+
+func (m *memoryTasks) updateGitData(ctx context.Context, id pgt.UUID, change gitDataChange) (db.Task, error) {
+	task, err := m.GetTask(ctx, id)
+	if err != nil {
+		return db.Task{}, err
+	}
+	encoded, status, err := changeGitData(m.workflow, task, change)
+	if err != nil {
+		return db.Task{}, err
+	}
+	task.GitData = encoded
+	if status != "" {
+		task.Status = pgt.Text{String: status, Valid: true}
+	}
+	m.tasks[id] = task
+	return task, nil
+}
+
+func newTestTask(status string) db.Task {
+	return db.Task{
+		ID:     pgt.UUID{Bytes: uuid.New(), Valid: true},
+		Name:   "Fix login",
+		Status: pgt.Text{String: status, Valid: true},
+	}
+}
+
+func newTestPullRequestService(tasks *memoryTasks, provider *github.Fake) *PullRequestService {
+	return &PullRequestService{tasks: tasks, workflow: tasks.workflow, provider: provider}
+}
+
+var testRepo = domain.GitRepo{Owner: "acme", Name: "api", DefaultBranch: "main"}
+
+func TestAgentCommitAndPullRequestMoveNewTaskToReview(t *testing.T) {
+	ctx := context.Background()
+	workflow := domain.DefaultWorkflow()
+	task := newTestTask(workflow.InitialStatus)
+	tasks := newMemoryTasks(workflow, task)
+	service := newTestPullRequestService(tasks, github.NewFake())
+	branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+
+	task, err := tasks.updateGitData(ctx, task.ID, addCommit(workflow, domain.GitCommit{Repo: testRepo.FullName(), SHA: "0123abc", Branch: branch}))
+	if err != nil {
+		t.Fatalf("addCommit: %v", err)
+	}
+	if task.Status.String != inProgressStatus {
+		t.Fatalf("status after commit = %q, want %q", task.Status.String, inProgressStatus)
+	}
+
+	if _, err := service.OpenPullRequest(ctx, task, testRepo, branch); err != nil {
+		t.Fatalf("OpenPullRequest: %v", err)
+	}
+	task, _ = tasks.GetTask(ctx, task.ID)
+	if task.Status.String != reviewStatus {
+		t.Fatalf("status after pull request = %q, want %q", task.Status.String, reviewStatus)
+	}
+}
+
+func TestAddCommitKeepsStartedTask(t *testing.T) {
+	ctx := context.Background()
+	workflow := domain.DefaultWorkflow()
+	task := newTestTask(reviewStatus)
+	tasks := newMemoryTasks(workflow, task)
+
+	task, err := tasks.updateGitData(ctx, task.ID, addCommit(workflow, domain.GitCommit{Repo: testRepo.FullName(), SHA: "0123abc"}))
+	if err != nil {
+		t.Fatalf("addCommit: %v", err)
+	}
+	if task.Status.String != reviewStatus {
+		t.Errorf("status = %q, want %q", task.Status.String, reviewStatus)
+	}
+}
+
+func linkedPullRequests(t *testing.T, task db.Task) []domain.GitPullRequest {
+	t.Helper()
+	data, err := domain.ParseGitData(task.GitData)
+	if err != nil {
+		t.Fatalf("stored git_data: %v", err)
+	}
+	return data.PullRequests
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	ctx := context.Background()
+	task := newTestTask(inProgressStatus)
+	tasks := newMemoryTasks(domain.DefaultWorkflow(), task)
+	provider := github.NewFake()
+	service := newTestPullRequestService(tasks, provider)
+	branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+
+	pr, err := service.OpenPullRequest(ctx, task, testRepo, branch)
+	if err != nil {
+		t.Fatalf("OpenPullRequest: %v", err)
+	}
+	want := domain.GitPullRequest{
+		Repo:   "acme/api",
+		Number: 1,
+		URL:    "https://github.com/acme/api/pull/1",
+		State:  domain.PullRequestOpen,
+		Branch: branch,
+	}
+	if pr != want {
+		t.Errorf("pull request = %+v, want %+v", pr, want)
+	}
+	opened, err := provider.GetPullRequest(ctx, testRepo, 1)
+	if err != nil {
+		t.Fatalf("the provider has no pull request: %v", err)
+	}
+	if opened.Title != task.Name || opened.Head != branch || opened.Base != "main" {
+		t.Errorf("opened %+v, want %q from %s into main", opened, task.Name, branch)
+	}
+
+	task, _ = tasks.GetTask(ctx, task.ID)
+	if linked := linkedPullRequests(t, task); len(linked) != 1 || linked[0] != want {
+		t.Errorf("linked pull requests = %+v, want [%+v]", linked, want)
+	}
+	if task.Status.String != reviewStatus {
+		t.Errorf("status = %q, want %q", task.Status.String, reviewStatus)
+	}
+}
+
+func TestOpenPullRequestReusesOpenPullRequest(t *testing.T) {
+	ctx := context.Background()
+	task := newTestTask(inProgressStatus)
+	tasks := newMemoryTasks(domain.DefaultWorkflow(), task)
+	provider := github.NewFake()
+	service := newTestPullRequestService(tasks, provider)
+	branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+
+	first, err := service.OpenPullRequest(ctx, task, testRepo, branch)
+	if err != nil {
+		t.Fatalf("first OpenPullRequest: %v", err)
+	}
+	task, _ = tasks.GetTask(ctx, task.ID)
+	second, err := service.OpenPullRequest(ctx, task, testRepo, branch)
+	if err != nil {
+		t.Fatalf("second OpenPullRequest: %v", err)
+	}
+	if second != first {
+		t.Errorf("second pull request = %+v, want the first one %+v", second, first)
+	}
+	if _, err := provider.GetPullRequest(ctx, testRepo, 2); err == nil {
+		t.Error("a second pull request was opened")
+	}
+	task, _ = tasks.GetTask(ctx, task.ID)
+	if linked := linkedPullRequests(t, task); len(linked) != 1 {
+		t.Errorf("linked pull requests = %+v, want one", linked)
+	}
+}
+
+func TestRefreshPullRequest(t *testing.T) {
+	ctx := context.Background()
+	task := newTestTask(inProgressStatus)
+	tasks := newMemoryTasks(domain.DefaultWorkflow(), task)
+	provider := github.NewFake()
+	service := newTestPullRequestService(tasks, provider)
+	branch := domain.TaskBranchName(uuid.UUID(task.ID.Bytes).String())
+
+	pr, err := service.OpenPullRequest(ctx, task, testRepo, branch)
+	if err != nil {
+		t.Fatalf("OpenPullRequest: %v", err)
+	}
+	provider.SetState(testRepo, pr.Number, domain.PullRequestMerged)
+
+	task, err = service.RefreshPullRequest(ctx, task.ID, pr.Repo, pr.Number)
+	if err != nil {
+		t.Fatalf("RefreshPullRequest: %v", err)
+	}
+	if linked := linkedPullRequests(t, task); len(linked) != 1 || linked[0].State != domain.PullRequestMerged {
+		t.Errorf("linked pull requests = %+v, want #%d merged", linked, pr.Number)
+	}
+	if task.Status.String != doneStatus {
+		t.Errorf("status = %q, want %q", task.Status.String, doneStatus)
+	}
+}
+
+func TestRefreshPullRequestErrors(t *testing.T) {
+	ctx := context.Background()
+	task := newTestTask(inProgressStatus)
+	tasks := newMemoryTasks(domain.DefaultWorkflow(), task)
+	service := newTestPullRequestService(tasks, github.NewFake())
+
+	// Linked by hand, but unknown to the provider.
+	task, err := tasks.updateGitData(ctx, task.ID, linkPullRequest(domain.GitPullRequest{Repo: "acme/api", Number: 9, State: domain.PullRequestOpen}))
+	if err != nil {
+		t.Fatalf("linkPullRequest: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		taskID pgt.UUID
+		number int
+		want   error
+	}{
+		{"unknown task", pgt.UUID{Bytes: uuid.New(), Valid: true}, 9, ErrNotFound},
+		{"pull request not linked", task.ID, 10, ErrNotFound},
+		{"provider fails", task.ID, 9, ErrUpstream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.RefreshPullRequest(ctx, tt.taskID, "acme/api", tt.number)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("RefreshPullRequest = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddCommitKeepsLegacyGitData(t *testing.T) {
+	ctx := context.Background()
+	workflow := domain.DefaultWorkflow()
+	legacy := `{"repo":"acme/api","notes":"free-form"}`
+	task := newTestTask(inProgressStatus)
+	task.GitData = []byte(legacy)
+	tasks := newMemoryTasks(workflow, task)
+
+	task, err := tasks.updateGitData(ctx, task.ID, addCommit(workflow, domain.GitCommit{Repo: testRepo.FullName(), SHA: "0123abc"}))
+	if err != nil {
+		t.Fatalf("addCommit: %v", err)
+	}
+	data, err := domain.ParseGitData(task.GitData)
+	if err != nil {
+		t.Fatalf("stored git_data: %v", err)
+	}
+	if len(data.Commits) != 1 || data.Commits[0].SHA != "0123abc" {
+		t.Errorf("commits = %+v, want 0123abc", data.Commits)
+	}
+	if string(data.Legacy) != legacy {
+		t.Errorf("legacy = %s, want %s", data.Legacy, legacy)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// checkSchedule validates the dates and estimate of a task. Each is optional,
+// but a task can't be due before it starts and estimates can't be negative.
+func checkSchedule(start, due pgt.Date, points pgt.Int4) error {
+	if start.Valid && due.Valid && due.Time.Before(start.Time) {
+		return fmt.Errorf("%w: due_date %s is before start_date %s", ErrValidation,
+			due.Time.Format(time.DateOnly), start.Time.Format(time.DateOnly))
+	}
+	if points.Valid && points.Int32 < 0 {
+		return fmt.Errorf("%w: estimate_points must not be negative", ErrValidation)
+	}
+	return nil
+}
+
+// scheduleAfterUpdate returns the dates and estimate the task will have once
+// arg is applied.
+func scheduleAfterUpdate(current db.Task, arg db.UpdateTaskParams) (start, due pgt.Date, points pgt.Int4) {
+	start, due, points = current.StartDate, current.DueDate, current.EstimatePoints
+	if arg.SetStartDate {
+		start = arg.StartDate
+	}
+	if arg.SetDueDate {
+		due = arg.DueDate
+	}
+	if arg.SetEstimatePoints {
+		points = arg.EstimatePoints
+	}
+	return start, due, points
+}
+
+// TaskOverdue reports whether the task was due before the day of now and
+// isn't done or cancelled. It matches the overdue filter of ListTasks.
+func TaskOverdue(task db.Task, now time.Time) bool {
+	if !task.DueDate.Valid || slices.Contains(closedStatuses, task.Status.String) {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return task.DueDate.Time.Before(today)
+}
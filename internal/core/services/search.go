@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	db "shelke.dev/api/db/sqlc"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// The Search query marks matches with these instead of <mark>, so the text
+// around them can still be escaped.
+const (
+	matchStart = "\x02"
+	matchStop  = "\x03"
+)
+
+var markMatchReplacer = strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>")
+
+type SearchService struct {
+	queries *db.Queries
+}
+
+func NewSearchService(queries *db.Queries) *SearchService {
+	return &SearchService{queries: queries}
+}
+
+// Search runs a full-text search over task and feature names and
+// descriptions. query accepts web search syntax: quoted phrases, "or" and a
+// leading "-" to exclude a word. Names and snippets are safe HTML: the text
+// is escaped and matches are wrapped in <mark>.
+func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]db.SearchRow, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query is required", ErrInvalidInput)
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	results, err := s.queries.Search(ctx, db.SearchParams{
+		Query:       query,
+		ResultLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	for i := range results {
+		results[i].NameHighlight = markMatches(results[i].NameHighlight)
+		results[i].Snippet = markMatches(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// markMatches escapes a headline for HTML and wraps its matches in <mark>.
+func markMatches(headline string) string {
+	return markMatchReplacer.Replace(html.EscapeString(headline))
+}
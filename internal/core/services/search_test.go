@@ -0,0 +1,21 @@
+package services
+
+import "testing"
+
+func TestMarkMatches(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"Fix \x02login\x03 page", "Fix <mark>login</mark> page"},
+		{"<img src=x onerror=alert(1)> \x02login\x03", "&lt;img src=x onerror=alert(1)&gt; <mark>login</mark>"},
+		{"Tom & \x02Jerry\x03's \"show\"", "Tom &amp; <mark>Jerry</mark>&#39;s &#34;show&#34;"},
+		{"</mark><script>", "&lt;/mark&gt;&lt;script&gt;"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := markMatches(tt.headline); got != tt.want {
+			t.Errorf("markMatches(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
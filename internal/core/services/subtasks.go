@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// SubtaskProgress counts a task's subtasks at any depth, leaving out
+// cancelled ones, and how many of them are done.
+type SubtaskProgress struct {
+	Total int64
+	Done  int64
+}
+
+// Percent is the share of subtasks that are done, rounded down.
+func (p SubtaskProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return int(p.Done * 100 / p.Total)
+}
+
+// GetTaskTree returns the task and all of its subtasks, nested at any depth,
+// oldest first. Subtasks point at their parent through ParentTaskID.
+func (s *TaskService) GetTaskTree(ctx context.Context, id pgt.UUID) (db.Task, []db.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return db.Task{}, nil, err
+	}
+	subtasks, err := s.queries.ListSubtaskTree(ctx, id)
+	if err != nil {
+		return db.Task{}, nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+	return task, subtasks, nil
+}
+
+// listSubtaskProgress rolls up the subtasks of several tasks with one query.
+// Tasks without subtasks are left out of the result.
+func (s *TaskService) listSubtaskProgress(ctx context.Context, taskIDs []pgt.UUID) (map[pgt.UUID]SubtaskProgress, error) {
+	rows, err := s.queries.ListSubtaskProgress(ctx, db.ListSubtaskProgressParams{
+		TaskIds:         taskIDs,
+		CancelledStatus: cancelledStatus,
+		DoneStatus:      doneStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up subtasks: %w", err)
+	}
+	progress := make(map[pgt.UUID]SubtaskProgress, len(rows))
+	for _, row := range rows {
+		progress[row.TaskID] = SubtaskProgress{Total: row.Total, Done: row.Done}
+	}
+	return progress, nil
+}
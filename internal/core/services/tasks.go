@@ -2,23 +2,83 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+	"shelke.dev/api/internal/ports"
 )
 
 type TaskService struct {
-	queries *db.Queries
+	queries        *db.Queries
+	pool           *pgxpool.Pool
+	workflow       domain.Workflow
+	deriveFeatures bool
+	agent          AgentConfig
 }
 
-func NewTaskService(queries *db.Queries) *TaskService {
-	return &TaskService{queries: queries}
+// NewTaskService checks tasks against the task workflow of workflows, which
+// also says whether features are completed with their tasks. Runs queued by
+// assigning a task to the agent are retried as agent says.
+func NewTaskService(queries *db.Queries, pool *pgxpool.Pool, workflows domain.WorkflowConfig, agent AgentConfig) *TaskService {
+	return &TaskService{queries: queries, pool: pool, workflow: workflows.Task, deriveFeatures: workflows.DeriveFeatureStatus, agent: agent}
 }
 
+// CreateTask creates a task in the workflow's initial status unless another
+// known status is given. Git data, if any, must match domain.GitData. A task
+// with a parent is a subtask and must be in its parent's feature. The task is
+// attributed to the authenticated user.
 func (s *TaskService) CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error) {
+	if err := requireMember(ctx, "creating tasks"); err != nil {
+		return db.Task{}, err
+	}
+	arg.CreatedBy = actorFromContext(ctx)
 	fmt.Printf("TaskService: Creating task with arguments: %+v\n", arg)
-	task, err := s.queries.CreateTask(ctx, arg)
+	if !arg.Status.Valid {
+		arg.Status = pgt.Text{String: s.workflow.InitialStatus, Valid: true}
+	}
+	if err := checkStatusChange(s.workflow, pgt.Text{}, arg.Status.String); err != nil {
+		return db.Task{}, err
+	}
+	if err := checkPriority(s.workflow, arg.Priority); err != nil {
+		return db.Task{}, err
+	}
+	if err := checkSchedule(arg.StartDate, arg.DueDate, arg.EstimatePoints); err != nil {
+		return db.Task{}, err
+	}
+	if arg.GitData != nil {
+		gitData, err := normalizeGitData(arg.GitData)
+		if err != nil {
+			return db.Task{}, err
+		}
+		arg.GitData = gitData
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		if arg.ParentTaskID.Valid {
+			parent, err := q.GetTaskForUpdate(ctx, arg.ParentTaskID)
+			if errors.Is(notFound(err), ErrNotFound) {
+				return fmt.Errorf("%w: parent task not found", ErrValidation)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get parent task: %w", err)
+			}
+			if parent.FeatureID != arg.FeatureID {
+				return fmt.Errorf("%w: a subtask must belong to its parent's feature", ErrValidation)
+			}
+		}
+
+		var err error
+		task, err = q.CreateTask(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionCreate, nil, taskAuditFields(task))
+	})
 	if err != nil {
 		fmt.Printf("TaskService: Failed to create task: %v\n", err)
 		return db.Task{}, err
@@ -27,20 +87,123 @@ func (s *TaskService) CreateTask(ctx context.Context, arg db.CreateTaskParams) (
 	return task, nil
 }
 
-func (s *TaskService) ListTasks(ctx context.Context) ([]db.Task, error) {
+// ListTasks returns one page of the tasks matching the filters in arg. The
+// sort and cursor fields of arg are filled in from page. The returned cursor
+// is empty when there are no more tasks. With arg.Overdue, only tasks due
+// before today that aren't done or cancelled are returned.
+func (s *TaskService) ListTasks(ctx context.Context, arg db.ListTasksParams, page ports.PageRequest) ([]db.Task, string, error) {
 	fmt.Println("TaskService: Listing tasks")
-	tasks, err := s.queries.ListTasks(ctx)
+	p, err := parsePage(page)
+	if err != nil {
+		return nil, "", err
+	}
+	arg.SortBy = p.sortBy
+	arg.Descending = p.descending()
+	arg.CursorTime = p.cursorTime
+	arg.CursorText = p.cursorText
+	arg.CursorID = p.cursorID
+	arg.PageLimit = p.queryLimit()
+	arg.ClosedStatuses = closedStatuses
+
+	tasks, err := s.queries.ListTasks(ctx, arg)
 	if err != nil {
 		fmt.Printf("TaskService: Failed to list tasks: %v\n", err)
-		return nil, err
+		return nil, "", err
 	}
 	fmt.Printf("TaskService: Successfully listed %d tasks\n", len(tasks))
-	return tasks, nil
+
+	if len(tasks) <= p.limit {
+		return tasks, "", nil
+	}
+	tasks = tasks[:p.limit]
+	last := tasks[len(tasks)-1]
+	sortTime, sortText := p.sortValues(last.CreatedAt, last.UpdatedAt, last.Name, last.Priority, last.Status)
+	return tasks, p.nextCursor(last.ID, sortTime, sortText), nil
 }
 
+// UpdateTask applies a partial update. A status change must be allowed by
+// the task workflow, and a task can't be started or finished while tasks
+// blocking it are open; the row is locked while the transition is checked.
+// Git data replaces the stored git data as a whole. Moving a task to another
+// feature moves its subtasks with it. Dates and estimates are only changed
+// when their set_* flag is, so they can be cleared. The feature may be
+// completed along with its last task, see deriveFeatureStatus.
 func (s *TaskService) UpdateTask(ctx context.Context, arg db.UpdateTaskParams) (db.Task, error) {
 	fmt.Printf("TaskService: Updating task with arguments: %+v\n", arg)
-	task, err := s.queries.UpdateTask(ctx, arg)
+	if err := requireMember(ctx, "updating tasks"); err != nil {
+		return db.Task{}, err
+	}
+	if err := checkPriority(s.workflow, arg.Priority); err != nil {
+		return db.Task{}, err
+	}
+	if arg.GitData != nil {
+		gitData, err := normalizeGitData(arg.GitData)
+		if err != nil {
+			return db.Task{}, err
+		}
+		arg.GitData = gitData
+	}
+
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		current, err := s.lockTask(ctx, q, arg.ID, arg.FeatureID)
+		if err != nil {
+			return err
+		}
+		if arg.Status.Valid {
+			if err := checkStatusChange(s.workflow, current.Status, arg.Status.String); err != nil {
+				return err
+			}
+			if arg.Status != current.Status {
+				if err := checkBlockers(ctx, q, arg.ID, arg.Status.String); err != nil {
+					return err
+				}
+			}
+		}
+		if err := checkSchedule(scheduleAfterUpdate(current, arg)); err != nil {
+			return err
+		}
+		moving := arg.FeatureID.Valid && arg.FeatureID != current.FeatureID
+		if moving && current.ParentTaskID.Valid {
+			return fmt.Errorf("%w: a subtask stays in its parent's feature, move the parent instead", ErrValidation)
+		}
+
+		task, err = q.UpdateTask(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionUpdate, taskAuditFields(current), taskAuditFields(task)); err != nil {
+			return err
+		}
+		if !moving {
+			if task.Status == current.Status {
+				return nil
+			}
+			return s.deriveFeatureStatus(ctx, q, task.FeatureID)
+		}
+		subtasks, err := q.ListSubtaskTree(ctx, task.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list subtasks: %w", err)
+		}
+		for _, subtask := range subtasks {
+			moved, err := q.UpdateTask(ctx, db.UpdateTaskParams{
+				ID:          subtask.ID,
+				FeatureID:   task.FeatureID,
+				FeatureName: task.FeatureName,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to move subtask: %w", err)
+			}
+			if err := recordAudit(ctx, q, AuditEntityTask, subtask.ID, AuditActionUpdate, taskAuditFields(subtask), taskAuditFields(moved)); err != nil {
+				return err
+			}
+		}
+		// The feature the task left may have only done tasks now.
+		if err := s.deriveFeatureStatus(ctx, q, current.FeatureID); err != nil {
+			return err
+		}
+		return s.deriveFeatureStatus(ctx, q, task.FeatureID)
+	})
 	if err != nil {
 		fmt.Printf("TaskService: Failed to update task: %v\n", err)
 		return db.Task{}, err
@@ -49,9 +212,41 @@ func (s *TaskService) UpdateTask(ctx context.Context, arg db.UpdateTaskParams) (
 	return task, nil
 }
 
+func (s *TaskService) GetTask(ctx context.Context, id pgt.UUID) (db.Task, error) {
+	fmt.Printf("TaskService: Getting task with ID: %v\n", id)
+	task, err := s.queries.GetTask(ctx, id)
+	if err != nil {
+		fmt.Printf("TaskService: Failed to get task: %v\n", err)
+		return db.Task{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	return task, nil
+}
+
+// DeleteTask moves the task and its subtasks to the trash, see TrashService.
 func (s *TaskService) DeleteTask(ctx context.Context, id pgt.UUID) error {
 	fmt.Printf("TaskService: Deleting task with ID: %v\n", id)
-	err := s.queries.DeleteTask(ctx, id)
+	if err := requireMember(ctx, "deleting tasks"); err != nil {
+		return err
+	}
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		current, err := s.lockTask(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		subtasks, err := q.ListSubtaskTree(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list subtasks: %w", err)
+		}
+		for _, task := range append([]db.Task{current}, subtasks...) {
+			if err := q.DeleteTask(ctx, task.ID); err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionDelete, taskAuditFields(task), nil); err != nil {
+				return err
+			}
+		}
+		return s.deriveFeatureStatus(ctx, q, current.FeatureID)
+	})
 	if err != nil {
 		fmt.Printf("TaskService: Failed to delete task: %v\n", err)
 		return fmt.Errorf("failed to delete task: %w", err)
@@ -59,3 +254,55 @@ func (s *TaskService) DeleteTask(ctx context.Context, id pgt.UUID) error {
 	fmt.Printf("TaskService: Task deleted successfully: %v\n", id)
 	return nil
 }
+
+// TaskDetails is what is shown with a task besides its own columns.
+type TaskDetails struct {
+	Dependencies []db.ListTaskDependencyLinksRow
+	// Subtasks is nil for tasks without subtasks.
+	Subtasks  *SubtaskProgress
+	Labels    []db.Label
+	Assignees []db.User
+}
+
+// ListTaskDetails loads the details of several tasks, with one query per
+// kind of detail, and returns them by task ID.
+func (s *TaskService) ListTaskDetails(ctx context.Context, taskIDs []pgt.UUID) (map[pgt.UUID]TaskDetails, error) {
+	dependencies, err := s.ListDependenciesByTask(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.listSubtaskProgress(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := listLabelsByTask(ctx, s.queries, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	assignees, err := listAssigneesByTask(ctx, s.queries, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	details := make(map[pgt.UUID]TaskDetails, len(taskIDs))
+	for _, id := range taskIDs {
+		detail := TaskDetails{
+			Dependencies: dependencies[id],
+			Labels:       labels[id],
+			Assignees:    assignees[id],
+		}
+		if p, ok := progress[id]; ok {
+			detail.Subtasks = &p
+		}
+		details[id] = detail
+	}
+	return details, nil
+}
+
+// GetTaskDetails loads the details of one task, see ListTaskDetails.
+func (s *TaskService) GetTaskDetails(ctx context.Context, id pgt.UUID) (TaskDetails, error) {
+	details, err := s.ListTaskDetails(ctx, []pgt.UUID{id})
+	if err != nil {
+		return TaskDetails{}, err
+	}
+	return details[id], nil
+}
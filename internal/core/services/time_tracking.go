@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
+)
+
+// minWorkLogDuration and maxWorkLogDuration bound manual entries, which are
+// usually typos when shorter than a minute or longer than a day.
+const (
+	minWorkLogDuration = time.Minute
+	maxWorkLogDuration = 24 * time.Hour
+)
+
+// TimeService tracks the time people spend on tasks, with timers or entries
+// logged by hand, and reports on it.
+type TimeService struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+}
+
+func NewTimeService(queries *db.Queries, pool *pgxpool.Pool) *TimeService {
+	return &TimeService{queries: queries, pool: pool}
+}
+
+// StartTimer starts a timer on the task for the authenticated user. Each user
+// has at most one timer running; starting another is a conflict.
+func (s *TimeService) StartTimer(ctx context.Context, taskID pgt.UUID) (db.WorkLog, error) {
+	user, err := timeTracker(ctx, "starting timers")
+	if err != nil {
+		return db.WorkLog{}, err
+	}
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return db.WorkLog{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+
+	log, err := s.queries.StartWorkLog(ctx, db.StartWorkLogParams{
+		TaskID:   taskID,
+		UserID:   user.ID,
+		UserName: user.Name,
+	})
+	if isUniqueViolation(err) {
+		running, err := s.queries.GetRunningWorkLog(ctx, user.ID)
+		if err != nil {
+			return db.WorkLog{}, fmt.Errorf("%w: a timer is already running", ErrConflict)
+		}
+		return db.WorkLog{}, fmt.Errorf("%w: a timer is already running on task %s, stop it first", ErrConflict, uuid.UUID(running.TaskID.Bytes))
+	}
+	if err != nil {
+		return db.WorkLog{}, fmt.Errorf("failed to start timer: %w", err)
+	}
+	return log, nil
+}
+
+// StopTimer stops the authenticated user's timer on the task.
+func (s *TimeService) StopTimer(ctx context.Context, taskID pgt.UUID) (db.WorkLog, error) {
+	user, err := timeTracker(ctx, "stopping timers")
+	if err != nil {
+		return db.WorkLog{}, err
+	}
+	log, err := s.queries.StopWorkLog(ctx, db.StopWorkLogParams{TaskID: taskID, UserID: user.ID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.WorkLog{}, fmt.Errorf("%w: no timer is running on the task", ErrNotFound)
+	}
+	if err != nil {
+		return db.WorkLog{}, fmt.Errorf("failed to stop timer: %w", err)
+	}
+	return log, nil
+}
+
+// LogWork records time the authenticated user spent on the task without a
+// timer. startedAt defaults to duration ago.
+func (s *TimeService) LogWork(ctx context.Context, taskID pgt.UUID, startedAt time.Time, duration time.Duration, note pgt.Text) (db.WorkLog, error) {
+	user, err := timeTracker(ctx, "logging work")
+	if err != nil {
+		return db.WorkLog{}, err
+	}
+	if duration < minWorkLogDuration || duration > maxWorkLogDuration {
+		return db.WorkLog{}, fmt.Errorf("%w: the time logged must be between %v and %v", ErrValidation, minWorkLogDuration, maxWorkLogDuration)
+	}
+	now := time.Now()
+	if startedAt.IsZero() {
+		startedAt = now.Add(-duration)
+	}
+	if startedAt.After(now) {
+		return db.WorkLog{}, fmt.Errorf("%w: work can't be logged in the future", ErrValidation)
+	}
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return db.WorkLog{}, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+
+	log, err := s.queries.CreateWorkLog(ctx, db.CreateWorkLogParams{
+		TaskID:    taskID,
+		UserID:    user.ID,
+		UserName:  user.Name,
+		StartedAt: pgt.Timestamptz{Time: startedAt, Valid: true},
+		EndedAt:   pgt.Timestamptz{Time: startedAt.Add(duration), Valid: true},
+		Note:      note,
+	})
+	if err != nil {
+		return db.WorkLog{}, fmt.Errorf("failed to log work: %w", err)
+	}
+	return log, nil
+}
+
+// ListWorkLogs returns the time logged on the task, oldest first, including
+// running timers.
+func (s *TimeService) ListWorkLogs(ctx context.Context, taskID pgt.UUID) ([]db.WorkLog, error) {
+	if _, err := s.queries.GetTask(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", notFound(err))
+	}
+	logs, err := s.queries.ListTaskWorkLogs(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list work logs: %w", err)
+	}
+	return logs, nil
+}
+
+// DeleteWorkLog deletes a work log. Only the user who logged it and admins
+// may delete it.
+func (s *TimeService) DeleteWorkLog(ctx context.Context, taskID, id pgt.UUID) error {
+	log, err := s.queries.GetWorkLog(ctx, db.GetWorkLogParams{ID: id, TaskID: taskID})
+	if err != nil {
+		return fmt.Errorf("failed to get work log: %w", notFound(err))
+	}
+	if err := requireAuthor(ctx, log.UserID, "deleting the work log"); err != nil {
+		return err
+	}
+	if err := s.queries.DeleteWorkLog(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete work log: %w", err)
+	}
+	return nil
+}
+
+// TimeReport totals the finished work logs that started in [from, to),
+// optionally only those of one feature or one user.
+func (s *TimeService) TimeReport(ctx context.Context, from, to time.Time, featureID, userID pgt.UUID) (domain.TimeReport, error) {
+	if !from.Before(to) {
+		return domain.TimeReport{}, fmt.Errorf("%w: from must be before to", ErrInvalidInput)
+	}
+	rows, err := s.queries.ListWorkLogEntries(ctx, db.ListWorkLogEntriesParams{
+		StartedAfter:  pgt.Timestamptz{Time: from, Valid: true},
+		StartedBefore: pgt.Timestamptz{Time: to, Valid: true},
+		FeatureID:     featureID,
+		UserID:        userID,
+	})
+	if err != nil {
+		return domain.TimeReport{}, fmt.Errorf("failed to list work logs: %w", err)
+	}
+
+	entries := make([]domain.TimeEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = domain.TimeEntry{
+			ID:          uuid.UUID(row.WorkLog.ID.Bytes).String(),
+			TaskID:      uuid.UUID(row.WorkLog.TaskID.Bytes).String(),
+			TaskName:    row.TaskName,
+			FeatureID:   uuid.UUID(row.FeatureID.Bytes).String(),
+			FeatureName: row.FeatureName,
+			UserName:    row.WorkLog.UserName,
+			StartedAt:   row.WorkLog.StartedAt.Time,
+			EndedAt:     row.WorkLog.EndedAt.Time,
+			Note:        row.WorkLog.Note.String,
+		}
+		if row.WorkLog.UserID.Valid {
+			entries[i].UserID = uuid.UUID(row.WorkLog.UserID.Bytes).String()
+		}
+	}
+	return domain.NewTimeReport(from, to, entries), nil
+}
+
+// WorkLogDuration is the time a work log covers, up to now for a running
+// timer.
+func WorkLogDuration(log db.WorkLog, now time.Time) time.Duration {
+	if !log.EndedAt.Valid {
+		return now.Sub(log.StartedAt.Time)
+	}
+	return log.EndedAt.Time.Sub(log.StartedAt.Time)
+}
+
+// timeTracker returns the user time is tracked for. Timers and work logs
+// belong to a person, so the system can't track time, and viewers can't
+// either.
+func timeTracker(ctx context.Context, action string) (db.User, error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return db.User{}, fmt.Errorf("%w: %s needs an authenticated user", ErrInvalidInput, action)
+	}
+	if err := requireMember(ctx, action); err != nil {
+		return db.User{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "shelke.dev/api/db/sqlc"
+)
+
+// TrashService manages soft-deleted tasks and features: listing them,
+// restoring them and purging them for good once the retention period is over.
+type TrashService struct {
+	queries   *db.Queries
+	pool      *pgxpool.Pool
+	retention time.Duration
+}
+
+func NewTrashService(queries *db.Queries, pool *pgxpool.Pool, retention time.Duration) *TrashService {
+	return &TrashService{queries: queries, pool: pool, retention: retention}
+}
+
+func (s *TrashService) ListTrash(ctx context.Context) ([]db.Task, []db.Feature, error) {
+	tasks, err := s.queries.ListDeletedTasks(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list deleted tasks: %w", err)
+	}
+	features, err := s.queries.ListDeletedFeatures(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list deleted features: %w", err)
+	}
+	return tasks, features, nil
+}
+
+// RestoreTask takes a task out of the trash, with the subtasks that were
+// deleted along with it. Its feature, and its parent task for a subtask, have
+// to be restored first if they were deleted too.
+func (s *TrashService) RestoreTask(ctx context.Context, id pgt.UUID) (db.Task, error) {
+	if err := requireMember(ctx, "restoring tasks"); err != nil {
+		return db.Task{}, err
+	}
+	var task db.Task
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		deleted, err := q.GetDeletedTaskForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted task: %w", notFound(err))
+		}
+		_, err = q.GetFeature(ctx, deleted.FeatureID)
+		if errors.Is(notFound(err), ErrNotFound) {
+			return fmt.Errorf("%w: the task's feature is deleted, restore it first", ErrConflict)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get feature: %w", err)
+		}
+		if deleted.ParentTaskID.Valid {
+			_, err = q.GetTask(ctx, deleted.ParentTaskID)
+			if errors.Is(notFound(err), ErrNotFound) {
+				return fmt.Errorf("%w: the task's parent is deleted, restore it first", ErrConflict)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get parent task: %w", err)
+			}
+		}
+		subtasks, err := q.ListTrashedSubtaskTree(ctx, db.ListTrashedSubtaskTreeParams{DeletedAt: deleted.DeletedAt, ID: id})
+		if err != nil {
+			return fmt.Errorf("failed to list deleted subtasks: %w", err)
+		}
+
+		task, err = q.RestoreTask(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to restore task: %w", err)
+		}
+		if err := recordAudit(ctx, q, AuditEntityTask, id, AuditActionRestore, nil, taskAuditFields(task)); err != nil {
+			return err
+		}
+		for _, subtask := range subtasks {
+			restored, err := q.RestoreTask(ctx, subtask.ID)
+			if err != nil {
+				return fmt.Errorf("failed to restore subtask: %w", err)
+			}
+			if err := recordAudit(ctx, q, AuditEntityTask, subtask.ID, AuditActionRestore, nil, taskAuditFields(restored)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return db.Task{}, err
+	}
+	return task, nil
+}
+
+// RestoreFeature takes a feature out of the trash, with the tasks that were
+// deleted along with it by a cascading DeleteFeature. Tasks deleted on their
+// own before that stay in the trash. Only the feature's owners and admins may
+// restore it.
+func (s *TrashService) RestoreFeature(ctx context.Context, id pgt.UUID) (db.Feature, error) {
+	var feature db.Feature
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		deleted, err := q.GetDeletedFeatureForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted feature: %w", notFound(err))
+		}
+		if err := requireFeatureOwner(ctx, q, id, "restoring the feature"); err != nil {
+			return err
+		}
+		tasks, err := q.ListTrashedFeatureTasks(ctx, db.ListTrashedFeatureTasksParams{FeatureID: id, DeletedAt: deleted.DeletedAt})
+		if err != nil {
+			return fmt.Errorf("failed to list deleted tasks: %w", err)
+		}
+
+		feature, err = q.RestoreFeature(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to restore feature: %w", err)
+		}
+		if err := recordAudit(ctx, q, AuditEntityFeature, id, AuditActionRestore, nil, featureAuditFields(feature)); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			restored, err := q.RestoreTask(ctx, task.ID)
+			if err != nil {
+				return fmt.Errorf("failed to restore task: %w", err)
+			}
+			if err := recordAudit(ctx, q, AuditEntityTask, task.ID, AuditActionRestore, nil, taskAuditFields(restored)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return db.Feature{}, err
+	}
+	return feature, nil
+}
+
+// Purge permanently removes tasks and features that have been in the trash
+// for longer than the retention period. It returns how many rows were removed.
+// Tasks with logged work, the tasks above them and their features are kept,
+// so the time report still covers the time spent on them.
+func (s *TrashService) Purge(ctx context.Context) (int64, int64, error) {
+	deletedBefore := pgt.Timestamptz{Time: time.Now().Add(-s.retention), Valid: true}
+
+	var tasks, features int64
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		tasks, err = q.PurgeDeletedTasks(ctx, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("failed to purge tasks: %w", err)
+		}
+		if err := q.PurgeDeletedFeatureOwners(ctx, deletedBefore); err != nil {
+			return fmt.Errorf("failed to purge feature owners: %w", err)
+		}
+		features, err = q.PurgeDeletedFeatures(ctx, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("failed to purge features: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return tasks, features, nil
+}
+
+// RunPurger calls Purge every interval until ctx is cancelled.
+func (s *TrashService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		tasks, features, err := s.Purge(ctx)
+		if err != nil {
+			log.Printf("TrashService: Purge failed: %v", err)
+		} else if tasks > 0 || features > 0 {
+			log.Printf("TrashService: Purged %d tasks and %d features", tasks, features)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type UserService struct {
+	queries *db.Queries
+	pool    *pgxpool.Pool
+}
+
+func NewUserService(queries *db.Queries, pool *pgxpool.Pool) *UserService {
+	return &UserService{queries: queries, pool: pool}
+}
+
+// CreateUser creates a user, attributed to the authenticated user if there
+// is one. Only admins manage users.
+func (s *UserService) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	if err := requireAdmin(ctx, "creating users"); err != nil {
+		return db.User{}, err
+	}
+	if err := checkRole(arg.Role); err != nil {
+		return db.User{}, err
+	}
+	arg.CreatedBy = actorFromContext(ctx)
+	user, err := s.queries.CreateUser(ctx, arg)
+	if err != nil {
+		return db.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context) ([]db.User, error) {
+	users, err := s.queries.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id pgt.UUID) (db.User, error) {
+	user, err := s.queries.GetUser(ctx, id)
+	if err != nil {
+		return db.User{}, fmt.Errorf("failed to get user: %w", notFound(err))
+	}
+	return user, nil
+}
+
+// UpdateUser updates the user and refreshes the denormalized name and role on
+// every feature_owners row, comment and work log that references it. Only
+// admins manage users.
+func (s *UserService) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+	if err := requireAdmin(ctx, "updating users"); err != nil {
+		return db.User{}, err
+	}
+	if arg.Role.Valid {
+		if arg.ID == AgentUserID {
+			return db.User{}, fmt.Errorf("%w: the agent user's role can't be changed", ErrValidation)
+		}
+		if err := checkRole(arg.Role.String); err != nil {
+			return db.User{}, err
+		}
+	}
+	var user db.User
+	err := withTx(ctx, s.pool, s.queries, func(q *db.Queries) error {
+		var err error
+		user, err = q.UpdateUser(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", notFound(err))
+		}
+
+		err = q.SyncFeatureOwnerUser(ctx, db.SyncFeatureOwnerUserParams{
+			UserID:   user.ID,
+			UserName: pgt.Text{String: user.Name, Valid: true},
+			UserRole: pgt.Text{String: user.Role, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync feature owners: %w", err)
+		}
+
+		err = q.SyncCommentAuthorName(ctx, db.SyncCommentAuthorNameParams{
+			AuthorID:   user.ID,
+			AuthorName: user.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync comment authors: %w", err)
+		}
+
+		err = q.SyncWorkLogUserName(ctx, db.SyncWorkLogUserNameParams{
+			UserID:   user.ID,
+			UserName: user.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync work logs: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, id pgt.UUID) error {
+	if err := requireAdmin(ctx, "deleting users"); err != nil {
+		return err
+	}
+	if id == AgentUserID {
+		return fmt.Errorf("%w: the agent user can't be deleted", ErrConflict)
+	}
+	err := s.queries.DeleteUser(ctx, id)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("%w: the user still owns features, remove them from the features' owners first", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
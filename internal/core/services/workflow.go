@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	"shelke.dev/api/internal/core/domain"
+)
+
+type WorkflowService struct {
+	config domain.WorkflowConfig
+}
+
+func NewWorkflowService(config domain.WorkflowConfig) *WorkflowService {
+	return &WorkflowService{config: config}
+}
+
+func (s *WorkflowService) Workflows() domain.WorkflowConfig {
+	return s.config
+}
+
+// checkStatusChange validates moving from the stored status to a new one.
+func checkStatusChange(workflow domain.Workflow, from pgt.Text, to string) error {
+	if !workflow.HasStatus(to) {
+		return fmt.Errorf("%w: unknown status %q, expected one of: %s", ErrValidation, to, strings.Join(workflow.Statuses, ", "))
+	}
+	if from.Valid && !workflow.CanTransition(from.String, to) {
+		return fmt.Errorf("%w: cannot move from %q to %q, allowed: %s", ErrInvalidTransition, from.String, to, strings.Join(workflow.NextStatuses(from.String), ", "))
+	}
+	return nil
+}
+
+func checkPriority(workflow domain.Workflow, priority pgt.Text) error {
+	if priority.Valid && !workflow.HasPriority(priority.String) {
+		return fmt.Errorf("%w: unknown priority %q, expected one of: %s", ErrValidation, priority.String, strings.Join(workflow.Priorities, ", "))
+	}
+	return nil
+}
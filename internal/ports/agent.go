@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type AgentService interface {
+	StartRun(ctx context.Context, taskID pgt.UUID) (db.AgentRun, error)
+	GetRun(ctx context.Context, id pgt.UUID) (db.AgentRun, error)
+	ListRuns(ctx context.Context, taskID pgt.UUID) ([]db.AgentRun, error)
+	CancelRun(ctx context.Context, id pgt.UUID) (db.AgentRun, error)
+}
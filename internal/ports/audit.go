@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type AuditService interface {
+	ListHistory(ctx context.Context, entityType string, entityID pgt.UUID) ([]db.AuditEvent, error)
+}
@@ -9,7 +9,30 @@ import (
 
 type FeatureService interface {
 	CreateFeature(ctx context.Context, arg db.CreateFeatureParams) (db.Feature, error)
-	ListFeatures(ctx context.Context) ([]db.Feature, error)
+	ListFeatures(ctx context.Context, arg db.ListFeaturesParams, page PageRequest) ([]db.Feature, string, error)
+	GetFeature(ctx context.Context, id pgt.UUID) (db.Feature, error)
 	UpdateFeature(ctx context.Context, arg db.UpdateFeatureParams) (db.Feature, error)
-	DeleteFeature(ctx context.Context, id pgt.UUID) error
+	DeleteFeature(ctx context.Context, id pgt.UUID, opts DeleteFeatureOptions) error
+	AddFeatureOwner(ctx context.Context, featureID pgt.UUID, userID pgt.UUID) (db.FeatureOwner, error)
+	RemoveFeatureOwner(ctx context.Context, featureID pgt.UUID, userID pgt.UUID) error
+	ListFeatureOwners(ctx context.Context, featureID pgt.UUID) ([]db.FeatureOwner, error)
+	ListOwnersByFeature(ctx context.Context, featureIDs []pgt.UUID) (map[pgt.UUID][]db.FeatureOwner, error)
+}
+
+// FeatureDeleteMode decides what happens to a feature's tasks when the
+// feature is deleted.
+type FeatureDeleteMode string
+
+const (
+	// FeatureDeleteRestrict refuses to delete a feature that still has tasks.
+	FeatureDeleteRestrict FeatureDeleteMode = ""
+	// FeatureDeleteCascade moves the feature's tasks to the trash with it.
+	FeatureDeleteCascade FeatureDeleteMode = "cascade"
+	// FeatureDeleteReassign moves the feature's tasks to another feature.
+	FeatureDeleteReassign FeatureDeleteMode = "reassign"
+)
+
+type DeleteFeatureOptions struct {
+	Mode       FeatureDeleteMode
+	ReassignTo pgt.UUID // target feature, only used by FeatureDeleteReassign
 }
@@ -0,0 +1,22 @@
+package ports
+
+import "context"
+
+// LLMRequest is a single prompt sent to a language model.
+type LLMRequest struct {
+	SystemPrompt string // instructions that apply to the whole conversation
+	Prompt       string
+}
+
+// LLMResponse is the model's answer to an LLMRequest.
+type LLMResponse struct {
+	Text  string
+	Model string // model that produced the answer, as reported by the provider
+}
+
+// LLMProvider is a language model the coding agent can talk to.
+type LLMProvider interface {
+	// Name identifies the provider, e.g. "gemini".
+	Name() string
+	Generate(ctx context.Context, req LLMRequest) (LLMResponse, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+// PageRequest describes which page of a list endpoint the caller wants.
+type PageRequest struct {
+	Sort   string // column to sort by, defaults to created_at
+	Order  string // "asc" or "desc", defaults to desc
+	Cursor string // opaque cursor returned as next_cursor by the previous page
+	Limit  int    // page size, defaults to 50
+}
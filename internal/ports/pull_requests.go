@@ -0,0 +1,70 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"shelke.dev/api/internal/core/domain"
+)
+
+// NewPullRequest describes a pull request to open.
+type NewPullRequest struct {
+	Title string
+	Body  string
+	Head  string // branch with the changes
+	Base  string // branch to merge into, the repo's default branch when empty
+}
+
+// PullRequest is a pull request as reported by the provider.
+type PullRequest struct {
+	Number int
+	URL    string
+	State  string // domain.PullRequestOpen, PullRequestClosed or PullRequestMerged
+	Title  string
+	Head   string
+	Base   string
+}
+
+// ReviewComment is a comment left on a pull request's diff.
+type ReviewComment struct {
+	ID        int64
+	Author    string
+	Body      string
+	Path      string
+	Line      int
+	URL       string
+	CreatedAt time.Time
+}
+
+// Review states of a pull request review.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewCommented        = "commented"
+)
+
+// PullRequestEvent reports that a pull request was opened, updated, closed
+// or reviewed on the code host.
+type PullRequestEvent struct {
+	Action      string // e.g. "opened", "closed" or "submitted"
+	Repo        domain.GitRepo
+	PullRequest PullRequest
+	ReviewState string // set for reviews, one of the Review* states
+}
+
+// PushEvent reports commits pushed to a branch, or the branch's deletion.
+type PushEvent struct {
+	Repo    domain.GitRepo
+	Branch  string
+	Deleted bool
+	Commits []domain.GitCommit
+}
+
+// PullRequestProvider opens and inspects pull requests on a code host.
+type PullRequestProvider interface {
+	// CreatePullRequest opens a pull request, or returns the open one if
+	// there already is one for the head branch.
+	CreatePullRequest(ctx context.Context, repo domain.GitRepo, pr NewPullRequest) (PullRequest, error)
+	GetPullRequest(ctx context.Context, repo domain.GitRepo, number int) (PullRequest, error)
+	ListReviewComments(ctx context.Context, repo domain.GitRepo, number int) ([]ReviewComment, error)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"context"
+
+	db "shelke.dev/api/db/sqlc"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, query string, limit int) ([]db.SearchRow, error)
+}
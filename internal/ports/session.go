@@ -0,0 +1,12 @@
+package ports
+
+import "time"
+
+// SessionTokens issues and verifies signed session tokens, which identify a
+// user until they expire without a database lookup.
+type SessionTokens interface {
+	Issue(userID string, expiresAt time.Time) (string, error)
+	// Verify checks the token's signature and expiry and returns the ID of
+	// the user it was issued to.
+	Verify(token string) (string, error)
+}
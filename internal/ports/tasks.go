@@ -5,11 +5,17 @@ import (
 
 	pgt "github.com/jackc/pgx/v5/pgtype"
 	db "shelke.dev/api/db/sqlc"
+	"shelke.dev/api/internal/core/domain"
 )
 
 type TaskService interface {
 	CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error)
-	ListTasks(ctx context.Context) ([]db.Task, error)
+	ListTasks(ctx context.Context, arg db.ListTasksParams, page PageRequest) ([]db.Task, string, error)
+	GetTask(ctx context.Context, id pgt.UUID) (db.Task, error)
 	UpdateTask(ctx context.Context, arg db.UpdateTaskParams) (db.Task, error)
 	DeleteTask(ctx context.Context, id pgt.UUID) error
+	AddTaskBranch(ctx context.Context, id pgt.UUID, branch domain.GitBranch) (db.Task, error)
+	RemoveTaskBranch(ctx context.Context, id pgt.UUID, repo, name string) (db.Task, error)
+	AddTaskPullRequest(ctx context.Context, id pgt.UUID, pr domain.GitPullRequest) (db.Task, error)
+	RemoveTaskPullRequest(ctx context.Context, id pgt.UUID, repo string, number int) (db.Task, error)
 }
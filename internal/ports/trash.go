@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type TrashService interface {
+	ListTrash(ctx context.Context) ([]db.Task, []db.Feature, error)
+	RestoreTask(ctx context.Context, id pgt.UUID) (db.Task, error)
+	RestoreFeature(ctx context.Context, id pgt.UUID) (db.Feature, error)
+	Purge(ctx context.Context) (int64, int64, error)
+}
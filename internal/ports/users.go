@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	db "shelke.dev/api/db/sqlc"
+)
+
+type UserService interface {
+	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error)
+	ListUsers(ctx context.Context) ([]db.User, error)
+	GetUser(ctx context.Context, id pgt.UUID) (db.User, error)
+	UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error)
+	DeleteUser(ctx context.Context, id pgt.UUID) error
+}
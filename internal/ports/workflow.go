@@ -0,0 +1,7 @@
+package ports
+
+import "shelke.dev/api/internal/core/domain"
+
+type WorkflowService interface {
+	Workflows() domain.WorkflowConfig
+}
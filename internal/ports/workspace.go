@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"shelke.dev/api/internal/core/domain"
+)
+
+// RepoWorkspace checks out repositories in a scratch directory, shows their
+// files and commits changes to them.
+type RepoWorkspace interface {
+	// ReadRepo checks out branch of repo like CommitPatch and returns its
+	// tracked paths, with the contents of the files that mentions refer to
+	// (see domain.MatchPaths). Binary files and files too large to show are
+	// left out of the contents.
+	ReadRepo(ctx context.Context, repo domain.GitRepo, branch string, mentions []string) (domain.RepoSnapshot, error)
+	// CommitPatch checks out branch of repo, creating it from the repo's
+	// default branch when it doesn't exist yet, applies the unified diff,
+	// commits it with message and pushes the branch. It returns the SHA of
+	// the new commit.
+	CommitPatch(ctx context.Context, repo domain.GitRepo, branch, diff, message string) (string, error)
+}
@@ -1,13 +1,31 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
+	pgt "github.com/jackc/pgx/v5/pgtype"
+	dbsqlc "shelke.dev/api/db/sqlc"
 	_ "shelke.dev/api/docs" // docs is generated by Swag CLI, you have to import it.
+	"shelke.dev/api/internal/adapters/config"
 	"shelke.dev/api/internal/adapters/db"
+	"shelke.dev/api/internal/adapters/github"
+	"shelke.dev/api/internal/adapters/gitworkspace"
 	httphandler "shelke.dev/api/internal/adapters/http"
+	"shelke.dev/api/internal/adapters/jwt"
+	"shelke.dev/api/internal/adapters/llm"
+	"shelke.dev/api/internal/core/domain"
 	"shelke.dev/api/internal/core/services"
+	"shelke.dev/api/internal/ports"
 )
 
 // @title Portfolio API
@@ -24,6 +42,12 @@ import (
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer " followed by a personal API token or a session token. Only /health, /swagger and /webhooks are public.
+// @security BearerAuth
 func main() {
 	dbQueries, pool, err := db.NewDB()
 	if err != nil {
@@ -31,12 +55,174 @@ func main() {
 	}
 	defer pool.Close()
 
+	workflows := domain.DefaultWorkflowConfig()
+	if path := os.Getenv("WORKFLOW_CONFIG"); path != "" {
+		workflows, err = config.LoadWorkflow(path)
+		if err != nil {
+			log.Fatalf("Could not load workflow config: %v", err)
+		}
+	}
+
+	sessionKey := []byte(os.Getenv("JWT_SECRET"))
+	if len(sessionKey) == 0 {
+		log.Println("JWT_SECRET is not set, using a random key: session tokens won't survive a restart")
+		sessionKey = make([]byte, 32)
+		if _, err := rand.Read(sessionKey); err != nil {
+			log.Fatalf("Could not generate session key: %v", err)
+		}
+	}
+	sessionTTL, err := durationFromEnv("SESSION_TTL", 12*time.Hour)
+	if err != nil {
+		log.Fatalf("Invalid SESSION_TTL: %v", err)
+	}
+	authService := services.NewAuthService(dbQueries, jwt.NewSigner(sessionKey), sessionTTL)
+
+	if len(os.Args) > 1 && os.Args[1] == "create-token" {
+		if err := createToken(context.Background(), authService, services.NewUserService(dbQueries, pool), os.Args[2:]); err != nil {
+			log.Fatalf("create-token: %v", err)
+		}
+		return
+	}
+
+	trashRetention, err := durationFromEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		log.Fatalf("Invalid TRASH_RETENTION: %v", err)
+	}
+	purgeInterval, err := durationFromEnv("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatalf("Invalid TRASH_PURGE_INTERVAL: %v", err)
+	}
+	trashService := services.NewTrashService(dbQueries, pool, trashRetention)
+	go trashService.RunPurger(context.Background(), purgeInterval)
+
+	var llmProvider ports.LLMProvider
+	switch provider := os.Getenv("LLM_PROVIDER"); provider {
+	case "", "gemini":
+		llmProvider = llm.NewGemini(os.Getenv("GEMINI_API_KEY"), os.Getenv("GEMINI_MODEL"))
+	case "fake":
+		llmProvider = llm.NewFake()
+	default:
+		log.Fatalf("Unknown LLM_PROVIDER %q, expected gemini or fake", provider)
+	}
+
+	agentConfig := services.DefaultAgentConfig()
+	if agentConfig.Workers, err = intFromEnv("AGENT_WORKERS", agentConfig.Workers); err != nil {
+		log.Fatalf("Invalid AGENT_WORKERS: %v", err)
+	}
+	if agentConfig.MaxAttempts, err = intFromEnv("AGENT_MAX_ATTEMPTS", agentConfig.MaxAttempts); err != nil {
+		log.Fatalf("Invalid AGENT_MAX_ATTEMPTS: %v", err)
+	}
+	workspaceDir := os.Getenv("AGENT_WORKSPACE_DIR")
+	if workspaceDir == "" {
+		workspaceDir = filepath.Join(os.TempDir(), "portfolio-agent-workspaces")
+	}
+	workspace := gitworkspace.New(workspaceDir, envOr("AGENT_GIT_NAME", "Portfolio Agent"), envOr("AGENT_GIT_EMAIL", "agent@shelke.dev"))
+
+	var pullRequestProvider ports.PullRequestProvider
+	switch provider := os.Getenv("PR_PROVIDER"); provider {
+	case "", "github":
+		pullRequestProvider = github.NewClient(os.Getenv("GITHUB_TOKEN"), os.Getenv("GITHUB_API_URL"))
+	case "fake":
+		pullRequestProvider = github.NewFake()
+	default:
+		log.Fatalf("Unknown PR_PROVIDER %q, expected github or fake", provider)
+	}
+
+	taskService := services.NewTaskService(dbQueries, pool, workflows, agentConfig)
+	pullRequestService := services.NewPullRequestService(taskService, pullRequestProvider)
+	commentService := services.NewCommentService(dbQueries)
+	agentService := services.NewAgentService(dbQueries, taskService, pullRequestService, commentService, llmProvider, workspace, agentConfig)
+	go agentService.RunWorkers(context.Background())
+
 	healthCheckService := services.NewHealthCheckService()
-	server := httphandler.NewServer(healthCheckService, dbQueries)
+	server := httphandler.NewServer(healthCheckService, authService, trashService, taskService, agentService, pullRequestService, os.Getenv("GITHUB_WEBHOOK_SECRET"), dbQueries, pool, workflows)
 	server.Use(httphandler.LoggingMiddleware)
+	server.Use(httphandler.AuthMiddleware(authService))
 
 	log.Println("Server starting on port 8080...")
 	if err := http.ListenAndServe(":8080", server); err != nil {
 		log.Fatalf("Could not start server: %v", err)
 	}
 }
+
+// createToken prints a new personal API token, for a user that exists or one
+// it creates. Since every route needs a token, this is how the first admin
+// gets in:
+//
+//	api create-token -new-user "Ada" -role admin
+func createToken(ctx context.Context, authService *services.AuthService, userService *services.UserService, args []string) error {
+	flags := flag.NewFlagSet("create-token", flag.ContinueOnError)
+	userID := flags.String("user", "", "ID of the user the token is for")
+	newUser := flags.String("new-user", "", "name of a user to create the token for")
+	role := flags.String("role", "admin", "role of the user created with -new-user")
+	name := flags.String("name", "cli", "name of the token")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var user pgt.UUID
+	switch {
+	case *userID != "" && *newUser == "":
+		id, err := uuid.Parse(*userID)
+		if err != nil {
+			return fmt.Errorf("invalid -user: %w", err)
+		}
+		user = pgt.UUID{Bytes: id, Valid: true}
+	case *newUser != "" && *userID == "":
+		created, err := userService.CreateUser(ctx, dbsqlc.CreateUserParams{Name: *newUser, Role: *role})
+		if err != nil {
+			return err
+		}
+		user = created.ID
+		fmt.Fprintf(os.Stderr, "Created user %s\n", uuid.UUID(user.Bytes))
+	default:
+		return fmt.Errorf("pass either -user or -new-user")
+	}
+
+	_, token, err := authService.CreateAPITokenForUser(ctx, user, *name, pgt.Timestamptz{})
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+// durationFromEnv parses a positive time.Duration (e.g. "720h") from the
+// environment.
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", d)
+	}
+	return d, nil
+}
+
+// intFromEnv parses a positive integer from the environment.
+func intFromEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("must be at least 1, got %d", n)
+	}
+	return n, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}